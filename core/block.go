@@ -9,6 +9,7 @@ import (
 type BlockHeader struct {
 	GeneratorID []byte `json:"generator_id"`  // Block generator ID (Public key of generator)
 	PrevBlockID []byte `json:"prev_block_id"` // ID of previoud block
+	MerkleRoot  []byte `json:"merkle_root"`   // Merkle root of transactions
 	Timestamp   int    `json:"timestamp"`     // Timestamp of block generation
 }
 
@@ -22,6 +23,12 @@ type Block struct {
 // BlockSlice ...
 type BlockSlice []Block
 
+// SignedHeader ... Block header with signature of generator, without transactions.
+type SignedHeader struct {
+	Header    BlockHeader `json:"header"`    // Block header
+	Signature []byte      `json:"signature"` // Signature by generator
+}
+
 // EqualWith ... Test if two block headers are equal.
 func (bh BlockHeader) EqualWith(temp BlockHeader) bool {
 	if !bytes.Equal(StripBytes(bh.GeneratorID, 0), StripBytes(temp.GeneratorID, 0)) {
@@ -32,6 +39,10 @@ func (bh BlockHeader) EqualWith(temp BlockHeader) bool {
 		return false
 	}
 
+	if !bytes.Equal(bh.MerkleRoot, temp.MerkleRoot) {
+		return false
+	}
+
 	if bh.Timestamp != temp.Timestamp {
 		return false
 	}
@@ -39,6 +50,26 @@ func (bh BlockHeader) EqualWith(temp BlockHeader) bool {
 	return true
 }
 
+// Serialize ... Encode block header into canonical bytes.
+func (bh BlockHeader) Serialize() []byte {
+	return JoinBytesWithLength(
+		bh.GeneratorID,
+		bh.PrevBlockID,
+		bh.MerkleRoot,
+		UInt64ToBytes(uint64(bh.Timestamp)))
+}
+
+// ID ... Get block id, which is SHA256 sum of canonical header.
+// Header commits to transactions through merkle root, so headers could be verified without transactions.
+func (bh BlockHeader) ID() []byte {
+	return SHA256(bh.Serialize())
+}
+
+// VerifyMerkleProof ... Verify that transaction is included in block of this header.
+func (bh BlockHeader) VerifyMerkleProof(t Transaction, proof MerkleProof) bool {
+	return VerifyMerkleProof(bh.MerkleRoot, t, proof)
+}
+
 // MarshalJson ... Serialize block header into Json.
 func (bh BlockHeader) MarshalJson() ([]byte, error) {
 	return json.Marshal(bh)
@@ -49,6 +80,53 @@ func (bh *BlockHeader) UnmarshalJson(data []byte) error {
 	return json.Unmarshal(data, &bh)
 }
 
+// ID ... Get block id.
+func (b Block) ID() []byte {
+	return b.Header.ID()
+}
+
+// Sign ... Sign block with generator key pair.
+func (b *Block) Sign(kp *KeyPair) error {
+	sig, err := kp.Sign(b.ID())
+	if err != nil {
+		return err
+	}
+
+	b.Signature = sig
+
+	return nil
+}
+
+// VerifySignature ... Verify generator signature.
+func (b Block) VerifySignature() bool {
+	return VerifySignature(b.Header.GeneratorID, b.Signature, b.ID())
+}
+
+// SignedHeader ... Get header of block with its signature.
+func (b Block) SignedHeader() SignedHeader {
+	return SignedHeader{Header: b.Header, Signature: b.Signature}
+}
+
+// ID ... Get id of block of header.
+func (sh SignedHeader) ID() []byte {
+	return sh.Header.ID()
+}
+
+// VerifySignature ... Verify generator signature.
+func (sh SignedHeader) VerifySignature() bool {
+	return VerifySignature(sh.Header.GeneratorID, sh.Signature, sh.ID())
+}
+
+// VerifyMerkleRoot ... Verify that merkle root in header matches transactions.
+func (b Block) VerifyMerkleRoot() bool {
+	return bytes.Equal(b.Header.MerkleRoot, b.Transactions.MerkleRoot())
+}
+
+// MerkleProof ... Get inclusion proof of transaction with given id.
+func (b Block) MerkleProof(id []byte) (bool, MerkleProof) {
+	return b.Transactions.MerkleProof(id)
+}
+
 // AppendNewTransaction ... Append new transaction to this block.
 func (b *Block) AppendNewTransaction(t Transaction) {
 	ts := b.Transactions.Append(t)
@@ -125,6 +203,28 @@ func (bs *BlockSlice) UnmarshalJson(data []byte) error {
 	return json.Unmarshal(data, &bs)
 }
 
+// GetMerkleProof ... Get block header and inclusion proof of transaction with given id.
+func (bs BlockSlice) GetMerkleProof(id []byte) (bool, BlockHeader, MerkleProof) {
+	for _, b := range bs {
+		if ok, proof := b.MerkleProof(id); ok {
+			return true, b.Header, proof
+		}
+	}
+
+	return false, BlockHeader{}, MerkleProof{}
+}
+
+// GetTransactionByPrevID ... Get non-genesis transaction which claims given previous transaction.
+func (bs BlockSlice) GetTransactionByPrevID(prevID []byte) (bool, Transaction) {
+	for _, b := range bs {
+		if t, tr := b.Transactions.GetTransactionByPrevID(prevID); t {
+			return true, tr
+		}
+	}
+
+	return false, Transaction{}
+}
+
 // GetTransactionByID ... Get transaction by transaction id.
 func (bs BlockSlice) GetTransactionByID(id []byte) (bool, Transaction) {
 	for _, b := range bs {
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"math/rand"
 	"testing"
@@ -11,6 +12,7 @@ func GenRandomBlockHeader() BlockHeader {
 	return BlockHeader{
 		GenRandomBytes(64),
 		GenRandomBytes(32),
+		GenRandomBytes(32),
 		rand.Intn(10000)}
 }
 
@@ -95,3 +97,40 @@ func TestBlockSliceMarshalJson(t *testing.T) {
 		panic(errors.New("(BlockSlice) MarshalJson()/UnmarshalJson() testing failed"))
 	}
 }
+
+// Test block id and signature.
+func TestBlockIDAndSignature(t *testing.T) {
+	kp, _ := NewECDSAKeyPair()
+
+	b1 := GenRandomBlock(10)
+	b1.Header.GeneratorID = kp.Public
+	b1.AppendNewTransaction(GenRandomTransaction())
+	b1.Header.MerkleRoot = b1.Transactions.MerkleRoot()
+
+	err := b1.Sign(kp)
+	if err != nil {
+		panic(err)
+	}
+
+	if !b1.VerifySignature() {
+		panic(errors.New("(Block) VerifySignature() testing failed"))
+	}
+
+	// ID should survive Json round trip.
+	b1json, _ := b1.MarshalJson()
+
+	var b2 Block
+
+	_ = b2.UnmarshalJson(b1json)
+
+	if !bytes.Equal(b1.ID(), b2.ID()) || !b2.VerifySignature() {
+		panic(errors.New("(Block) ID() should be independent of Json encoding"))
+	}
+
+	// Any change of transactions should break merkle root.
+	b2.Transactions[len(b2.Transactions)-1].Output.Accepted++
+
+	if !b1.VerifyMerkleRoot() || b2.VerifyMerkleRoot() {
+		panic(errors.New("(Block) VerifyMerkleRoot() testing failed"))
+	}
+}
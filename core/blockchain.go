@@ -1,5 +1,13 @@
 package core
 
+import (
+	"bytes"
+	"fmt"
+)
+
+// BlockTimestampTolerance ... Block could be earlier than its previous block within this tolerance (seconds).
+const BlockTimestampTolerance = 15
+
 // Blockchain ...
 type Blockchain struct {
 	Blocks BlockSlice // Stored block chain
@@ -13,3 +21,288 @@ func (bc Blockchain) GetTransactionByID(id []byte) (bool, Transaction) {
 
 	return false, Transaction{}
 }
+
+// GetTransactionByPrevID ... Get non-genesis transaction which claims given previous transaction.
+func (bc Blockchain) GetTransactionByPrevID(prevID []byte) (bool, Transaction) {
+	return bc.Blocks.GetTransactionByPrevID(prevID)
+}
+
+// GetMerkleProof ... Get block header and inclusion proof of transaction with given id.
+func (bc Blockchain) GetMerkleProof(id []byte) (bool, BlockHeader, MerkleProof) {
+	return bc.Blocks.GetMerkleProof(id)
+}
+
+// Height ... Get number of blocks in chain.
+func (bc Blockchain) Height() int {
+	return len(bc.Blocks)
+}
+
+// LastBlock ... Get the last block (tip) of chain.
+func (bc Blockchain) LastBlock() (bool, Block) {
+	if len(bc.Blocks) == 0 {
+		return false, Block{}
+	}
+
+	return true, bc.Blocks[len(bc.Blocks)-1]
+}
+
+// AppendBlock ... Append new block to chain.
+func (bc *Blockchain) AppendBlock(b Block) {
+	bc.Blocks = append(bc.Blocks, b)
+}
+
+// ValidationError ... Error of chain validation, it names the first offending block/transaction.
+type ValidationError struct {
+	BlockIndex    int    // Index of offending block
+	BlockID       []byte // ID of offending block
+	TransactionID []byte // ID of offending transaction, nil if block itself is invalid
+	Reason        string // Reason
+}
+
+// Error ... Implement error interface.
+func (e *ValidationError) Error() string {
+	if e.TransactionID != nil {
+		return fmt.Sprintf("invalid transaction %s in block #%d (%s): %s",
+			Base58Encode(e.TransactionID), e.BlockIndex, Base58Encode(e.BlockID), e.Reason)
+	}
+
+	return fmt.Sprintf("invalid block #%d (%s): %s", e.BlockIndex, Base58Encode(e.BlockID), e.Reason)
+}
+
+// Validate ... Validate every block and transaction of chain.
+func (bc Blockchain) Validate() error {
+	v := newChainValidator(nil)
+
+	for i, b := range bc.Blocks {
+		var prev *Block
+		if i > 0 {
+			prev = &bc.Blocks[i-1]
+		}
+
+		err := v.check(i, prev, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateBlock ... Validate block on top of chain, as Validate() would do for it.
+// Chain itself is trusted to be valid, so only the new block costs signature verification.
+// It replays transactions of chain, node validates blocks against index of its chain instead.
+func (bc Blockchain) ValidateBlock(b Block) error {
+	v := newChainValidator(nil)
+
+	for _, block := range bc.Blocks {
+		v.accept(block)
+	}
+
+	var prev *Block
+	if found, last := bc.LastBlock(); found {
+		prev = &last
+	}
+
+	return v.check(bc.Height(), prev, b)
+}
+
+// chainState ... Transactions of valid chain which validator works on top of.
+type chainState interface {
+	hasTransaction(id []byte) bool
+	lastTransactionOf(pk []byte) (bool, Transaction)
+	isRotated(pk []byte) bool
+}
+
+// indexedChain ... Blocks of chain below given height, looked up through index of chain.
+type indexedChain struct {
+	chain  Blockchain
+	index  *ChainIndex
+	height int
+}
+
+// hasTransaction ... Implement chainState.
+func (c indexedChain) hasTransaction(id []byte) bool {
+	found, loc := c.index.LocateTransaction(id)
+	return found && loc.Height < c.height
+}
+
+// lastTransactionOf ... Implement chainState.
+func (c indexedChain) lastTransactionOf(pk []byte) (bool, Transaction) {
+	locs := c.index.LocateTransactionsByRequester(pk)
+
+	for i := len(locs) - 1; i >= 0; i-- {
+		if locs[i].Height < c.height {
+			return true, c.chain.Blocks[locs[i].Height].Transactions[locs[i].Index]
+		}
+	}
+
+	return false, Transaction{}
+}
+
+// isRotated ... Implement chainState.
+func (c indexedChain) isRotated(pk []byte) bool {
+	for _, loc := range c.index.LocateTransactionsByRequestee(pk) {
+		if loc.Height < c.height && c.chain.Blocks[loc.Height].Transactions[loc.Index].IsKeyRotation() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// chainValidator ... State of chain validation, on top of base chain.
+type chainValidator struct {
+	base    chainState             // Valid chain below validated blocks, nil if there is none
+	seen    map[string]bool        // Transaction ids seen so far
+	lastOf  map[string]Transaction // Last transaction of requester
+	rotated map[string]bool        // Keys rotated so far
+}
+
+// newChainValidator ... Generate validator on top of given chain, nil for empty chain.
+func newChainValidator(base chainState) *chainValidator {
+	return &chainValidator{
+		base:    base,
+		seen:    make(map[string]bool),
+		lastOf:  make(map[string]Transaction),
+		rotated: make(map[string]bool),
+	}
+}
+
+// hasSeen ... Test if transaction has been seen in base chain or validated blocks.
+func (v *chainValidator) hasSeen(id []byte) bool {
+	return v.seen[Base58Encode(id)] || (v.base != nil && v.base.hasTransaction(id))
+}
+
+// isRotated ... Test if key has been rotated in base chain or validated blocks.
+func (v *chainValidator) isRotated(pk []byte) bool {
+	return v.rotated[Base58Encode(pk)] || (v.base != nil && v.base.isRotated(pk))
+}
+
+// lastTransactionOf ... Get last transaction of requester in base chain or validated blocks.
+func (v *chainValidator) lastTransactionOf(pk []byte) (bool, Transaction) {
+	if t, ok := v.lastOf[Base58Encode(pk)]; ok {
+		return true, t
+	}
+
+	if v.base != nil {
+		return v.base.lastTransactionOf(pk)
+	}
+
+	return false, Transaction{}
+}
+
+// accept ... Record transactions of valid block without checking it.
+func (v *chainValidator) accept(b Block) {
+	for _, t := range b.Transactions {
+		v.seen[Base58Encode(t.ID())] = true
+		v.lastOf[Base58Encode(t.RequesterPK())] = t
+
+		if t.IsKeyRotation() {
+			v.retire(t.RequesteePK())
+		}
+	}
+}
+
+// retire ... Record rotated key, it could not request anymore.
+func (v *chainValidator) retire(pk []byte) {
+	v.rotated[Base58Encode(pk)] = true
+	delete(v.lastOf, Base58Encode(pk))
+}
+
+// check ... Validate block at given index on top of previous block, nil if it's the first block, and record it.
+func (v *chainValidator) check(i int, prev *Block, b Block) error {
+	blockErr := func(reason string) error {
+		return &ValidationError{BlockIndex: i, BlockID: b.ID(), Reason: reason}
+	}
+
+	if prev == nil {
+		if len(b.Header.PrevBlockID) != 0 {
+			return blockErr("first block should not have previous block")
+		}
+	} else {
+		if !bytes.Equal(b.Header.PrevBlockID, prev.ID()) {
+			return blockErr("previous block id mismatch")
+		}
+
+		if b.Header.Timestamp < prev.Header.Timestamp-BlockTimestampTolerance {
+			return blockErr("timestamp is earlier than previous block")
+		}
+	}
+
+	if !b.VerifySignature() {
+		return blockErr("invalid generator signature")
+	}
+
+	if !b.VerifyMerkleRoot() {
+		return blockErr("merkle root mismatch")
+	}
+
+	for _, t := range b.Transactions {
+		trErr := func(reason string) error {
+			return &ValidationError{BlockIndex: i, BlockID: b.ID(), TransactionID: t.ID(), Reason: reason}
+		}
+
+		if v.hasSeen(t.ID()) {
+			return trErr("duplicate transaction")
+		}
+		v.seen[Base58Encode(t.ID())] = true
+
+		if !t.VerifyTransactionID() {
+			return trErr("invalid transaction id")
+		}
+
+		if !t.VerifyRequesterSig() {
+			return trErr("invalid requester signature")
+		}
+
+		if !t.VerifyRequesteeSig() {
+			return trErr("invalid requestee signature")
+		}
+
+		if v.isRotated(t.RequesterPK()) {
+			return trErr("requester key has been rotated")
+		}
+
+		found, last := v.lastTransactionOf(t.RequesterPK())
+
+		if t.IsKeyRotation() {
+			// Key rotation continues chain of old key as requestee, in a new key.
+			if found {
+				return trErr("new key of rotation has transactions already")
+			}
+
+			if v.isRotated(t.RequesteePK()) {
+				return trErr("old key of rotation has been rotated already")
+			}
+
+			var prev *Transaction
+			if ok, lastOld := v.lastTransactionOf(t.RequesteePK()); ok {
+				prev = &lastOld
+			}
+
+			if err := VerifyKeyRotation(prev, t); err != nil {
+				return trErr(err.Error())
+			}
+
+			v.retire(t.RequesteePK())
+		} else if t.IsGenesisTransaction() {
+			if found {
+				return trErr("requester has genesis transaction already")
+			}
+
+			if err := VerifyGenesisCredits(t); err != nil {
+				return trErr(err.Error())
+			}
+		} else if !found {
+			return trErr("requester has no genesis transaction")
+		} else if !bytes.Equal(t.PreviousID(), last.ID()) {
+			return trErr("previous transaction id breaks requester's chain")
+		} else if err := VerifyCredits(last, t); err != nil {
+			return trErr(err.Error())
+		}
+
+		v.lastOf[Base58Encode(t.RequesterPK())] = t
+	}
+
+	return nil
+}
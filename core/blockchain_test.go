@@ -0,0 +1,146 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Generate new transaction signed by requester, waiting for decision of requestee.
+func GenRequestedTransaction(requester, requestee *Node, prev Transaction, data string) Transaction {
+	timestamp := int(time.Now().UnixNano())
+	id := SHA256(JoinBytes(requester.PublicKey(), requestee.PublicKey(), UInt64ToBytes(uint64(timestamp))))
+
+	t := Transaction{
+		Header: TransactionHeader{
+			TransactionID:      id,
+			Timestamp:          timestamp,
+			PrevTransactionID:  prev.ID(),
+			RequesterPublicKey: requester.PublicKey(),
+			RequesteePublicKey: requestee.PublicKey(),
+		},
+		Meta:   []byte(data),
+		Output: prev.Out(),
+	}
+
+	t.Header.RequesterSignature = requester.Sign(t.RequesterHash())
+
+	return t
+}
+
+// Generate new transaction signed by both requester and requestee.
+func GenSignedTransaction(requester, requestee *Node, prev Transaction, data string) Transaction {
+	return requestee.ConfirmTransaction(GenRequestedTransaction(requester, requestee, prev, data))
+}
+
+// Generate valid chain with given number of blocks, each one with given number of transactions.
+func GenValidChain(blocks, trs int) (*Node, Blockchain) {
+	generator, _ := NewNode("127.0.0.1", 0)
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	prev := requester.NewGenesisTransaction([]byte("genesis"))
+	generator.CheckAndAddTransactionToPool(prev)
+
+	for i := 0; i < blocks; i++ {
+		for j := 0; j < trs; j++ {
+			prev = GenSignedTransaction(requester, requestee, prev, fmt.Sprintf("%d-%d", i, j))
+			generator.CheckAndAddTransactionToPool(prev)
+		}
+
+		generator.ProduceBlock()
+	}
+
+	return generator, generator.Chain
+}
+
+// Test chain validation.
+func TestBlockchainValidate(t *testing.T) {
+	_, bc := GenValidChain(3, 3)
+
+	if bc.Height() != 3 {
+		panic(fmt.Errorf("GenValidChain() testing failed"))
+	}
+
+	if err := bc.Validate(); err != nil {
+		panic(err)
+	}
+
+	// The last block validates on top of the others.
+	history := Blockchain{Blocks: bc.Blocks[:2]}
+	if err := history.ValidateBlock(bc.Blocks[2]); err != nil {
+		panic(err)
+	}
+
+	if err := history.ValidateBlock(bc.Blocks[1]); err == nil {
+		panic(fmt.Errorf("(Blockchain) ValidateBlock() accepted block twice"))
+	}
+
+	// Validate() should name the first offending block/transaction.
+	check := func(bc Blockchain, blockIndex int, transaction bool) {
+		var verr *ValidationError
+
+		if err := bc.Validate(); !errors.As(err, &verr) {
+			panic(fmt.Errorf("(Blockchain) Validate() accepted invalid chain"))
+		}
+
+		if verr.BlockIndex != blockIndex || (verr.TransactionID != nil) != transaction {
+			panic(fmt.Errorf("(Blockchain) Validate() reported wrong position: %v", verr))
+		}
+	}
+
+	tamper := func(f func(bs BlockSlice)) Blockchain {
+		bsjson, _ := bc.Blocks.MarshalJson()
+
+		var bs BlockSlice
+		_ = bs.UnmarshalJson(bsjson)
+
+		f(bs)
+
+		return Blockchain{Blocks: bs}
+	}
+
+	// Broken linkage.
+	check(tamper(func(bs BlockSlice) { bs[2].Header.PrevBlockID = GenRandomBytes(32) }), 2, false)
+
+	// Forged generator signature.
+	check(tamper(func(bs BlockSlice) { bs[1].Signature = bs[0].Signature }), 1, false)
+
+	// Timestamp going backward.
+	check(tamper(func(bs BlockSlice) { bs[1].Header.Timestamp -= 2 * BlockTimestampTolerance }), 1, false)
+
+	// Duplicate transaction.
+	check(tamper(func(bs BlockSlice) { bs[2].Transactions[0] = bs[0].Transactions[1] }), 2, false)
+
+	// Duplicate transaction, with block re-sealed by generator.
+	generator, _ := GenValidChain(1, 1)
+	forged := tamper(func(bs BlockSlice) {})
+	_, last := forged.LastBlock()
+	dup, _ := generator.NewBlock(last.ID(), last.Transactions)
+	forged.AppendBlock(dup)
+
+	check(forged, 3, true)
+}
+
+// Test genesis transaction claiming more credits than a fresh requester has.
+func TestInflatedGenesisTransaction(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	g, _ := NewNode("127.0.0.1", 0)
+	x, _ := NewNode("127.0.0.1", 0)
+
+	genesis := x.NewGenesisTransaction([]byte("genesis"))
+	genesis.Output.Accepted = 1000
+	genesis.Header.RequesterSignature = x.Sign(genesis.RequesterHash())
+	genesis = x.SignTransaction(genesis)
+
+	b, _ := g.NewBlock(nil, TransactionSlice{genesis})
+
+	if err := (Blockchain{Blocks: BlockSlice{b}}).Validate(); err == nil {
+		panic(fmt.Errorf("(Blockchain) Validate() accepted inflated genesis transaction"))
+	}
+
+	if _, err := n.AddBlock(b); err == nil || n.ReputationOf(x.PublicKey()).Transactions != 0 {
+		panic(fmt.Errorf("(*Node) AddBlock() accepted inflated genesis transaction"))
+	}
+}
@@ -0,0 +1,318 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrUnknownParentBlock ... Previous block of block is not known yet.
+var ErrUnknownParentBlock = errors.New("Unknown previous block")
+
+// BlockTree ... Every known valid block, main chain together with its side branches.
+type BlockTree struct {
+	blocks   map[string]Block // Block by id
+	heights  map[string]int   // Number of blocks on branch ending at block
+	children map[string]int   // Number of children of block
+}
+
+// NewBlockTree ... Generate new empty block tree.
+func NewBlockTree() *BlockTree {
+	return &BlockTree{
+		blocks:   make(map[string]Block),
+		heights:  make(map[string]int),
+		children: make(map[string]int),
+	}
+}
+
+// Add ... Add block into tree, its previous block should be in tree unless it's the first block.
+func (bt *BlockTree) Add(b Block) error {
+	id := Base58Encode(b.ID())
+	if _, ok := bt.blocks[id]; ok {
+		return nil
+	}
+
+	height := 1
+
+	if len(b.Header.PrevBlockID) != 0 {
+		prev := Base58Encode(b.Header.PrevBlockID)
+
+		if _, ok := bt.blocks[prev]; !ok {
+			return ErrUnknownParentBlock
+		}
+
+		height = bt.heights[prev] + 1
+		bt.children[prev]++
+	}
+
+	bt.blocks[id] = b
+	bt.heights[id] = height
+
+	return nil
+}
+
+// Has ... Test if block with given id is in tree.
+func (bt *BlockTree) Has(id []byte) bool {
+	_, ok := bt.blocks[Base58Encode(id)]
+	return ok
+}
+
+// Get ... Get block by id.
+func (bt *BlockTree) Get(id []byte) (bool, Block) {
+	b, ok := bt.blocks[Base58Encode(id)]
+	return ok, b
+}
+
+// Size ... Get number of blocks in tree.
+func (bt *BlockTree) Size() int {
+	return len(bt.blocks)
+}
+
+// Tips ... Get ids of blocks that no block extends, the highest first.
+func (bt *BlockTree) Tips() [][]byte {
+	var tips []Block
+
+	for id, b := range bt.blocks {
+		if bt.children[id] == 0 {
+			tips = append(tips, b)
+		}
+	}
+
+	sort.Slice(tips, func(i, j int) bool {
+		hi, hj := bt.heights[Base58Encode(tips[i].ID())], bt.heights[Base58Encode(tips[j].ID())]
+		if hi != hj {
+			return hi > hj
+		}
+
+		return bytes.Compare(tips[i].ID(), tips[j].ID()) < 0
+	})
+
+	var ids [][]byte
+	for _, b := range tips {
+		ids = append(ids, b.ID())
+	}
+
+	return ids
+}
+
+// Branch ... Get branch from the first block to block with given id, empty if block is not in tree.
+func (bt *BlockTree) Branch(id []byte) Blockchain {
+	b, ok := bt.blocks[Base58Encode(id)]
+	if !ok {
+		return Blockchain{}
+	}
+
+	blocks := make(BlockSlice, bt.heights[Base58Encode(id)])
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		blocks[i] = b
+		b = bt.blocks[Base58Encode(b.Header.PrevBlockID)]
+	}
+
+	return Blockchain{Blocks: blocks}
+}
+
+// forkChoice ... Get fork choice rule of node.
+func (n *Node) forkChoice() ForkChoice {
+	if n.ForkChoice == nil {
+		return LongestChain
+	}
+
+	return n.ForkChoice
+}
+
+// AddBlock ... Add block of other generator into block tree, it's validated on top of its own branch.
+// Main chain is switched to branch of block if fork choice rule prefers it, and true is returned.
+// Block extending tip of main chain is appended at once, fork choice only decides between branches.
+func (n *Node) AddBlock(b Block) (bool, error) {
+	if b.Header.Timestamp > int(time.Now().Unix())+BlockTimestampTolerance {
+		return false, ErrBlockFromFuture
+	}
+
+	n.ChainLock.Lock()
+	defer n.ChainLock.Unlock()
+
+	if n.Tree.Has(b.ID()) {
+		return false, nil
+	}
+
+	if len(b.Header.PrevBlockID) != 0 && !n.Tree.Has(b.Header.PrevBlockID) {
+		return false, ErrUnknownParentBlock
+	}
+
+	_, tip := n.Chain.LastBlock()
+	extendsTip := bytes.Equal(b.Header.PrevBlockID, tip.ID()) || (n.Chain.Height() == 0 && len(b.Header.PrevBlockID) == 0)
+
+	var branch Blockchain
+
+	if extendsTip {
+		branch = n.Chain
+	} else if len(b.Header.PrevBlockID) != 0 {
+		branch = n.Tree.Branch(b.Header.PrevBlockID)
+	}
+
+	err := branch.CheckBlockTiming(b, n.Consensus.minSpacing())
+	if err != nil {
+		return false, err
+	}
+
+	// Main chain is throttled through its time index.
+	if extendsTip {
+		err = n.checkBlockThrottle(b)
+	} else {
+		err = branch.CheckBlockThrottle(b, n.Throttle)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	err = n.validateBlock(b)
+	if err != nil {
+		return false, err
+	}
+
+	if extendsTip {
+		err = n.appendBlock(b)
+		if err != nil {
+			return false, err
+		}
+
+		n.RemoveTransactionsFromPool(b.Transactions)
+
+		return true, nil
+	}
+
+	n.Tree.Add(b)
+
+	branch.AppendBlock(b)
+
+	if !n.forkChoice().Prefer(branch, n.Chain) {
+		return false, nil
+	}
+
+	err = n.switchChain(branch)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// validateBlock ... Validate block on top of its branch in block tree.
+// Main chain is looked up through its index, so only blocks of branch after fork point are replayed.
+// NOTE: Caller should hold chain lock.
+func (n *Node) validateBlock(b Block) error {
+	var side BlockSlice // Blocks of branch which are not in main chain, the latest first
+	fork := 0
+
+	for id := b.Header.PrevBlockID; len(id) != 0; {
+		if found, height := n.Index.LocateBlock(id); found {
+			fork = height + 1
+			break
+		}
+
+		_, prev := n.Tree.Get(id)
+		side = append(side, prev)
+		id = prev.Header.PrevBlockID
+	}
+
+	v := newChainValidator(indexedChain{chain: n.Chain, index: n.Index, height: fork})
+
+	for i := len(side) - 1; i >= 0; i-- {
+		v.accept(side[i])
+	}
+
+	var prev *Block
+	if len(side) != 0 {
+		prev = &side[0]
+	} else if fork > 0 {
+		prev = &n.Chain.Blocks[fork-1]
+	}
+
+	return v.check(fork+len(side), prev, b)
+}
+
+// forkHeight ... Get number of leading blocks two chains share.
+// Block ids chain up, so chains never meet again once they diverge, and fork is binary searched.
+func forkHeight(a, b Blockchain) int {
+	return sort.Search(minInt(a.Height(), b.Height()), func(i int) bool {
+		return !bytes.Equal(a.Blocks[i].ID(), b.Blocks[i].ID())
+	})
+}
+
+// switchChain ... Reorganize main chain to given branch of block tree.
+// Transactions of orphaned blocks go back to transactions pool, and transactions of new blocks leave it.
+// If writing new blocks to store fails, old main chain is restored, so chain is never left half switched.
+// NOTE: Caller should hold chain lock.
+func (n *Node) switchChain(branch Blockchain) error {
+	fork := forkHeight(n.Chain, branch)
+
+	orphaned := append(BlockSlice{}, n.Chain.Blocks[fork:]...)
+	added := branch.Blocks[fork:]
+
+	err := n.replaceBlocks(fork, added)
+	if err != nil {
+		if e := n.replaceBlocks(fork, orphaned); e != nil {
+			// Chain ends at the last block which store keeps.
+			n.storeError(e)
+		}
+	}
+
+	// Pool follows whatever chain ends up with.
+	var back, sealed TransactionSlice
+
+	for _, b := range orphaned {
+		for _, t := range b.Transactions {
+			if found, _ := n.Index.LocateTransaction(t.ID()); !found {
+				back = append(back, t)
+			}
+		}
+	}
+
+	for _, b := range added {
+		for _, t := range b.Transactions {
+			if found, _ := n.Index.LocateTransaction(t.ID()); found {
+				sealed = append(sealed, t)
+			}
+		}
+	}
+
+	n.addTransactionsToPool(back)
+	n.RemoveTransactionsFromPool(sealed)
+
+	return err
+}
+
+// replaceBlocks ... Replace blocks of chain from given height on, they're written to store first,
+// so chain in memory never goes ahead of store, it ends at the last block written if writing fails.
+// NOTE: Caller should hold chain lock.
+func (n *Node) replaceBlocks(fork int, bs BlockSlice) error {
+	if n.Store != nil && n.Chain.Height() > fork {
+		err := n.Store.TruncateBlocks(fork)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := n.Chain.Height() - 1; i >= fork; i-- {
+		n.Index.RemoveBlock(i, n.Chain.Blocks[i])
+	}
+
+	n.Chain.Blocks = n.Chain.Blocks[:fork]
+
+	for _, b := range bs {
+		if n.Store != nil {
+			err := n.Store.AppendBlock(b)
+			if err != nil {
+				return err
+			}
+		}
+
+		n.Index.AddBlock(n.Chain.Height(), b)
+		n.Chain.AppendBlock(b)
+	}
+
+	return nil
+}
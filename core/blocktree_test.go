@@ -0,0 +1,240 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Test fork choice and reorganization of chain.
+func TestAddBlockReorg(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	n, _ := NewNodeWithStore("127.0.0.1", 0, s)
+	m, _ := NewNode("127.0.0.1", 0)
+	x, _ := NewNode("127.0.0.1", 0)
+	y, _ := NewNode("127.0.0.1", 0)
+	z, _ := NewNode("127.0.0.1", 0)
+
+	n.CheckAndAddTransactionToPool(x.NewGenesisTransaction([]byte("genesis")))
+	_, b0 := n.ProduceBlock()
+
+	if b, err := m.AddBlock(b0); !b || err != nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should extend chain: %v", err))
+	}
+
+	// Block without known parent waits for it.
+	orphan, _ := m.NewBlock(GenRandomBytes(32), nil)
+	if _, err := m.AddBlock(orphan); err != ErrUnknownParentBlock {
+		panic(fmt.Errorf("(*Node) AddBlock() should reject unknown parent, got %v", err))
+	}
+
+	// n and m extend the same block with competing branches.
+	ty := y.NewGenesisTransaction([]byte("genesis"))
+	n.CheckAndAddTransactionToPool(ty)
+	n.ProduceBlock()
+
+	m.CheckAndAddTransactionToPool(z.NewGenesisTransaction([]byte("genesis")))
+	_, b1 := m.ProduceBlock()
+
+	if b, err := n.AddBlock(b1); b || err != nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should keep main chain on tie: %v", err))
+	}
+
+	if tips := n.Tree.Tips(); len(tips) != 2 || n.Tree.Size() != 3 {
+		panic(fmt.Errorf("(*BlockTree) Tips() should keep side branch"))
+	}
+
+	w, _ := NewNode("127.0.0.1", 0)
+	m.CheckAndAddTransactionToPool(w.NewGenesisTransaction([]byte("genesis")))
+	_, b2 := m.ProduceBlock()
+
+	if b, err := n.AddBlock(b2); !b || err != nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should switch to longer branch: %v", err))
+	}
+
+	if h, _ := n.GetBlocksOfChain(); h != 3 || !bytes.Equal(n.tipID(), b2.ID()) {
+		panic(fmt.Errorf("(*Node) AddBlock() testing failed"))
+	}
+
+	if !n.IsInTransactionsPool(ty.ID()) {
+		panic(fmt.Errorf("(*Node) AddBlock() should move orphaned transactions back to pool"))
+	}
+
+	if err := n.ValidateChain(); err != nil {
+		panic(err)
+	}
+
+	// Store follows reorganized chain.
+	s.Close()
+
+	s, err = NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	bs, err := s.LoadBlocks()
+	if err != nil || len(bs) != 3 || !bytes.Equal(bs[2].ID(), b2.ID()) {
+		panic(fmt.Errorf("(*FileStore) TruncateBlocks() testing failed"))
+	}
+
+	// Invalid block is never added into tree.
+	forged := b2
+	forged.Header.Timestamp++
+	if _, err := n.AddBlock(forged); err == nil || n.Tree.Has(forged.ID()) {
+		panic(fmt.Errorf("(*Node) AddBlock() accepted invalid block"))
+	}
+}
+
+// Test validating blocks against index of main chain, on top of main chain and side branches.
+func TestAddBlockValidation(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	g, _ := NewNode("127.0.0.1", 0)
+	x, _ := NewNode("127.0.0.1", 0)
+	y, _ := NewNode("127.0.0.1", 0)
+
+	tx := x.NewGenesisTransaction([]byte("genesis"))
+	ty := y.NewGenesisTransaction([]byte("genesis"))
+
+	n.CheckAndAddTransactionToPool(tx)
+	_, b0 := n.ProduceBlock()
+
+	n.CheckAndAddTransactionToPool(ty)
+	_, b1 := n.ProduceBlock()
+
+	// Transaction in main chain could not be sealed again.
+	dup, _ := g.NewBlock(b1.ID(), TransactionSlice{tx})
+	if _, err := n.AddBlock(dup); err == nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should refuse transaction in main chain"))
+	}
+
+	// Side branch forks below the block of transaction, so it's sealed there again.
+	side, _ := g.NewBlock(b0.ID(), TransactionSlice{ty})
+	if _, err := n.AddBlock(side); err != nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should accept transaction above fork point: %v", err))
+	}
+
+	// Blocks of side branch are replayed.
+	again, _ := g.NewBlock(side.ID(), TransactionSlice{ty})
+	if _, err := n.AddBlock(again); err == nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should refuse transaction in side branch"))
+	}
+
+	y.SetGenesisTransaction(ty)
+	tz := x.ConfirmTransaction(y.NewPendingTransaction(x.PublicKey(), []byte("t")))
+
+	next, _ := g.NewBlock(side.ID(), TransactionSlice{tz})
+	if b, err := n.AddBlock(next); !b || err != nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should continue requester's chain of side branch: %v", err))
+	}
+}
+
+// failingStore ... Store whose appending of blocks fails after given number of blocks.
+type failingStore struct {
+	Store
+	appends int // Blocks appended before failing, negative to never fail
+}
+
+// AppendBlock ... Append block unless it should fail.
+func (s *failingStore) AppendBlock(b Block) error {
+	if s.appends == 0 {
+		s.appends = -1
+		return errors.New("Disk is full")
+	}
+
+	s.appends--
+
+	return s.Store.AppendBlock(b)
+}
+
+// Test restoring main chain if writing branch to store fails during reorganization.
+func TestSwitchChainStoreFailure(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	if err != nil {
+		panic(err)
+	}
+	defer fs.Close()
+
+	s := &failingStore{Store: fs, appends: -1}
+
+	n, _ := NewNodeWithStore("127.0.0.1", 0, s)
+	m, _ := NewNode("127.0.0.1", 0)
+	x, _ := NewNode("127.0.0.1", 0)
+	y, _ := NewNode("127.0.0.1", 0)
+
+	n.CheckAndAddTransactionToPool(x.NewGenesisTransaction([]byte("genesis")))
+	_, b0 := n.ProduceBlock()
+
+	ty := y.NewGenesisTransaction([]byte("genesis"))
+	n.CheckAndAddTransactionToPool(ty)
+	_, b1 := n.ProduceBlock()
+
+	m.AddBlock(b0)
+	s1, _ := m.NewBlock(b0.ID(), nil)
+	m.AddBlock(s1)
+	s2, _ := m.NewBlock(s1.ID(), nil)
+
+	n.AddBlock(s1)
+
+	// The first block of branch is written, the second one fails.
+	s.appends = 1
+
+	if b, err := n.AddBlock(s2); b || err == nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should report failure of store without switching"))
+	}
+
+	if h, _ := n.GetBlocksOfChain(); h != 2 || !bytes.Equal(n.tipID(), b1.ID()) || fs.Height() != 2 {
+		panic(fmt.Errorf("(*Node) AddBlock() should restore main chain when store fails"))
+	}
+
+	if loaded, _ := fs.LoadBlocks(); !loaded.EqualWith(n.Chain.Blocks) {
+		panic(fmt.Errorf("Store should keep restored main chain"))
+	}
+
+	if n.IsInTransactionsPool(ty.ID()) {
+		panic(fmt.Errorf("Transactions of restored main chain should not go back to pool"))
+	}
+
+	if err := n.ValidateChain(); err != nil {
+		panic(err)
+	}
+}
+
+// Test fork choice rules.
+func TestForkChoice(t *testing.T) {
+	_, bc := GenValidChain(2, 1)
+
+	short := Blockchain{Blocks: bc.Blocks[:1]}
+
+	if !LongestChain.Prefer(bc, short) || LongestChain.Prefer(short, bc) || LongestChain.Prefer(bc, bc) {
+		panic(fmt.Errorf("LongestChain testing failed"))
+	}
+
+	// Generator with a record outweighs unknown one.
+	trusted, _ := NewNode("127.0.0.1", 0)
+	unknown, _ := NewNode("127.0.0.1", 0)
+
+	trusted.CheckAndAddTransactionToPool(trusted.NewGenesisTransaction([]byte("genesis")))
+	_, b0 := trusted.ProduceBlock()
+
+	current := Blockchain{Blocks: BlockSlice{b0}}
+
+	a, _ := trusted.NewBlock(b0.ID(), nil)
+	b, _ := unknown.NewBlock(b0.ID(), nil)
+
+	if !MostTrustedChain.Prefer(ExtendChain(current, a), ExtendChain(current, b)) || MostTrustedChain.Prefer(ExtendChain(current, b), ExtendChain(current, a)) {
+		panic(fmt.Errorf("MostTrustedChain testing failed"))
+	}
+}
+
+// Extend copy of chain with block.
+func ExtendChain(bc Blockchain, b Block) Blockchain {
+	return Blockchain{Blocks: append(append(BlockSlice{}, bc.Blocks...), b)}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"time"
+)
+
+// SetCluster ... Join node into cluster, with configured head or elected one if head is empty.
+// Node without cluster is head of itself, so flat network works as before.
+func (n *Node) SetCluster(name string, head []byte) {
+	n.ClusterLock.Lock()
+	defer n.ClusterLock.Unlock()
+
+	n.Cluster = name
+	n.ClusterHeadPK = head
+}
+
+// ClusterName ... Get cluster of node.
+func (n *Node) ClusterName() string {
+	n.ClusterLock.RLock()
+	defer n.ClusterLock.RUnlock()
+
+	return n.Cluster
+}
+
+// GetClusterMembers ... Get nodes in routing table that declare the same cluster as node.
+func (n *Node) GetClusterMembers() (int, []RemoteNode) {
+	name := n.ClusterName()
+	if name == "" {
+		return 0, nil
+	}
+
+	_, nodes := n.GetNodesOfRoutingTable()
+
+	var members []RemoteNode
+
+	for _, rn := range nodes {
+		if rn.Cluster == name {
+			members = append(members, rn)
+		}
+	}
+
+	return len(members), members
+}
+
+// ClusterHead ... Get public key of cluster head.
+// Unless head is configured, the member with the smallest public key is elected,
+// every member knowing the same members elects the same head.
+func (n *Node) ClusterHead() []byte {
+	n.ClusterLock.RLock()
+	name, head := n.Cluster, n.ClusterHeadPK
+	n.ClusterLock.RUnlock()
+
+	if name == "" {
+		return n.PublicKey()
+	}
+
+	if len(head) != 0 {
+		return head
+	}
+
+	head = n.PublicKey()
+
+	_, members := n.GetClusterMembers()
+
+	for _, rn := range members {
+		if bytes.Compare(rn.PublicKey, head) < 0 {
+			head = rn.PublicKey
+		}
+	}
+
+	return head
+}
+
+// IsClusterHead ... Test if node is head of its cluster.
+// Only cluster heads keep transactions pool, produce blocks and store ledger, so light node is never head.
+func (n *Node) IsClusterHead() bool {
+	return !n.Light && bytes.Equal(n.ClusterHead(), n.PublicKey())
+}
+
+// GetClusterHeadNode ... Get cluster head from routing table, false if node is head itself or head is unknown.
+func (n *Node) GetClusterHeadNode() (bool, RemoteNode) {
+	head := n.ClusterHead()
+	if bytes.Equal(head, n.PublicKey()) {
+		return false, RemoteNode{}
+	}
+
+	return n.GetNodeByPublicKey(head)
+}
+
+// GetClusterHeads ... Get nodes in routing table that are heads, nodes without cluster are heads of themselves.
+func (n *Node) GetClusterHeads() (int, []RemoteNode) {
+	_, nodes := n.GetNodesOfRoutingTable()
+
+	var heads []RemoteNode
+
+	for _, rn := range nodes {
+		if rn.Cluster == "" || rn.Head {
+			heads = append(heads, rn)
+		}
+	}
+
+	return len(heads), heads
+}
+
+// Self ... Describe node as other nodes see it in their routing tables.
+func (n *Node) Self() RemoteNode {
+	return RemoteNode{
+		PublicKey: n.PublicKey(),
+		Address:   n.Addr(),
+		Lastseen:  int(time.Now().Unix()),
+		Cluster:   n.ClusterName(),
+		Head:      n.IsClusterHead(),
+	}
+}
+
+// NewPingMessage ... Generate new ping message, declaring cluster of node.
+func (n *Node) NewPingMessage() Message {
+	self := n.Self()
+
+	data := PingData{PublicKey: self.PublicKey, Address: self.Address, Cluster: self.Cluster, Head: self.Head}
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: Ping, Data: dataJSON}
+}
+
+// NewJoinMessage ... Generate new join message, declaring cluster of node.
+func (n *Node) NewJoinMessage() Message {
+	self := n.Self()
+
+	data := JoinData{PublicKey: self.PublicKey, Address: self.Address, Cluster: self.Cluster, Head: self.Head}
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: Join, Data: dataJSON}
+}
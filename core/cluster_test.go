@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Test cluster head election and duties of cluster members.
+func TestClusterHead(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	m, _ := NewNode("127.0.0.1", 0)
+
+	// Node without cluster is head of itself.
+	if !n.IsClusterHead() {
+		panic(fmt.Errorf("(*Node) IsClusterHead() should be true without cluster"))
+	}
+
+	n.SetCluster("a", nil)
+	m.SetCluster("a", nil)
+
+	n.CheckAndAddNodeToRoutingTable(m.Self())
+	m.CheckAndAddNodeToRoutingTable(n.Self())
+
+	if !bytes.Equal(n.ClusterHead(), m.ClusterHead()) || n.IsClusterHead() == m.IsClusterHead() {
+		panic(fmt.Errorf("(*Node) ClusterHead() should elect the same head on every member"))
+	}
+
+	head, member := n, m
+	if m.IsClusterHead() {
+		head, member = m, n
+	}
+
+	if b, rn := member.GetClusterHeadNode(); !b || !bytes.Equal(rn.PublicKey, head.PublicKey()) {
+		panic(fmt.Errorf("(*Node) GetClusterHeadNode() testing failed"))
+	}
+
+	if b, _ := head.GetClusterHeadNode(); b {
+		panic(fmt.Errorf("(*Node) GetClusterHeadNode() should be false on head"))
+	}
+
+	// Configured head overrides election.
+	member.SetCluster("a", member.PublicKey())
+	if !member.IsClusterHead() {
+		panic(fmt.Errorf("(*Node) SetCluster() should configure head"))
+	}
+	member.SetCluster("a", nil)
+
+	// Only head keeps pool and produces blocks.
+	for _, x := range []*Node{head, member} {
+		x.CheckAndAddTransactionToPool(x.NewGenesisTransaction([]byte("genesis")))
+	}
+
+	if c, _ := member.GetTransactionsOfPool(); c != 0 {
+		panic(fmt.Errorf("(*Node) CheckAndAddTransactionToPool() should be ignored by cluster member"))
+	}
+
+	if b, _ := member.ProduceBlock(); b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should be ignored by cluster member"))
+	}
+
+	if b, _ := head.ProduceBlock(); !b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() testing failed on cluster head"))
+	}
+
+	// Routing table of other node tells heads apart.
+	o, _ := NewNode("127.0.0.1", 0)
+	o.CheckAndAddNodeToRoutingTable(head.Self())
+	o.CheckAndAddNodeToRoutingTable(member.Self())
+
+	if c, heads := o.GetClusterHeads(); c != 1 || !bytes.Equal(heads[0].PublicKey, head.PublicKey()) {
+		panic(fmt.Errorf("(*Node) GetClusterHeads() testing failed"))
+	}
+}
+
+// Test following own transactions without ledger.
+func TestIsContinuationOfPrevTransaction(t *testing.T) {
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	genesis := requester.NewGenesisTransaction([]byte("genesis"))
+	requester.SetGenesisTransaction(genesis)
+
+	pending := requester.NewPendingTransaction(requestee.PublicKey(), []byte("data"))
+
+	if requester.IsContinuationOfPrevTransaction(requestee.SignTransaction(pending)) {
+		panic(fmt.Errorf("(*Node) IsContinuationOfPrevTransaction() accepted transaction without decision"))
+	}
+
+	confirmed := requestee.ConfirmTransaction(pending)
+
+	if !requester.IsContinuationOfPrevTransaction(confirmed) {
+		panic(fmt.Errorf("(*Node) IsContinuationOfPrevTransaction() testing failed"))
+	}
+
+	if requestee.IsContinuationOfPrevTransaction(confirmed) {
+		panic(fmt.Errorf("(*Node) IsContinuationOfPrevTransaction() accepted transaction of other node"))
+	}
+}
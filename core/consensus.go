@@ -0,0 +1,143 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Errors of timing rules.
+var (
+	ErrBlockTooSoon    = errors.New("Block is produced too soon after previous block of its generator")
+	ErrBlockFromFuture = errors.New("Block timestamp is in the future")
+)
+
+// MinProducePeriod ... Block producer waits at least this long between attempts, even without timing rules.
+const MinProducePeriod = time.Second
+
+// ConsensusConfig ... Timing rules of distributed time-based consensus.
+// Every generator waits a random time in [MinWait, MaxWait] before producing block, and restarts waiting
+// if other generator extends chain meanwhile, so generators rarely produce blocks at the same time.
+// Zero value imposes no timing rules.
+type ConsensusConfig struct {
+	MinWait    time.Duration // Lower bound of random wait before producing block
+	MaxWait    time.Duration // Upper bound of random wait before producing block
+	MinSpacing time.Duration // Minimum time between two blocks of the same generator
+}
+
+// RandomWait ... Draw random wait before producing block.
+func (c ConsensusConfig) RandomWait() time.Duration {
+	if c.MaxWait <= c.MinWait {
+		return c.MinWait
+	}
+
+	return c.MinWait + time.Duration(rand.Int63n(int64(c.MaxWait-c.MinWait)+1))
+}
+
+// produceWait ... Draw wait of block producer, it's never shorter than MinProducePeriod.
+func (c ConsensusConfig) produceWait() time.Duration {
+	if w := c.RandomWait(); w > MinProducePeriod {
+		return w
+	}
+
+	return MinProducePeriod
+}
+
+// minSpacing ... Minimum spacing in seconds, as block timestamps are.
+func (c ConsensusConfig) minSpacing() int {
+	return int(c.MinSpacing / time.Second)
+}
+
+// LastBlockOf ... Get last block produced by given generator.
+func (bc Blockchain) LastBlockOf(generatorID []byte) (bool, Block) {
+	for i := len(bc.Blocks) - 1; i >= 0; i-- {
+		if bytes.Equal(bc.Blocks[i].Header.GeneratorID, generatorID) {
+			return true, bc.Blocks[i]
+		}
+	}
+
+	return false, Block{}
+}
+
+// CheckBlockTiming ... Check if block keeps minimum spacing (seconds) after previous block of its generator in chain.
+func (bc Blockchain) CheckBlockTiming(b Block, minSpacing int) error {
+	found, last := bc.LastBlockOf(b.Header.GeneratorID)
+	if found && b.Header.Timestamp-last.Header.Timestamp < minSpacing {
+		return ErrBlockTooSoon
+	}
+
+	return nil
+}
+
+// ValidateTiming ... Check if every generator keeps minimum spacing (seconds) between its blocks.
+func (bc Blockchain) ValidateTiming(minSpacing int) error {
+	lastOf := make(map[string]int) // Timestamp of last block of generator
+
+	for i, b := range bc.Blocks {
+		generator := Base58Encode(b.Header.GeneratorID)
+
+		if last, found := lastOf[generator]; found && b.Header.Timestamp-last < minSpacing {
+			return &ValidationError{BlockIndex: i, BlockID: b.ID(), Reason: "generator produced block too soon"}
+		}
+
+		lastOf[generator] = b.Header.Timestamp
+	}
+
+	return nil
+}
+
+// CheckBlockTiming ... Check if block from other generator follows timing rules on top of chain of node.
+func (n *Node) CheckBlockTiming(b Block) error {
+	if b.Header.Timestamp > int(time.Now().Unix())+BlockTimestampTolerance {
+		return ErrBlockFromFuture
+	}
+
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.Chain.CheckBlockTiming(b, n.Consensus.minSpacing())
+}
+
+// canProduceBlock ... Test if node has waited long enough since its last block, and has block budget left.
+// NOTE: Caller should hold chain lock.
+func (n *Node) canProduceBlock(timestamp int) bool {
+	found, last := n.Chain.LastBlockOf(n.PublicKey())
+	if found && timestamp-last.Header.Timestamp < n.Consensus.minSpacing() {
+		return false
+	}
+
+	return n.budgetOf(n.PublicKey(), timestamp).Remaining != 0
+}
+
+// RunBlockProducer ... Produce blocks following distributed time-based consensus.
+// Node waits a random time, and produces block only if no other generator has extended chain meanwhile.
+// New tip is announced to cluster heads, so they sync it.
+func (n *Node) RunBlockProducer() {
+	for {
+		tip := n.tipID()
+
+		time.Sleep(n.Consensus.produceWait())
+
+		if !bytes.Equal(tip, n.tipID()) {
+			// Other generator is faster, wait again on top of its block.
+			continue
+		}
+
+		if b, _ := n.ProduceBlock(); b {
+			n.AnnounceTip()
+		}
+	}
+}
+
+// tipID ... Get id of last block of chain, empty if chain is empty.
+func (n *Node) tipID() []byte {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	if b, last := n.Chain.LastBlock(); b {
+		return last.ID()
+	}
+
+	return nil
+}
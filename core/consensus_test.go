@@ -0,0 +1,85 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Test random wait of consensus.
+func TestRandomWait(t *testing.T) {
+	c := ConsensusConfig{MinWait: time.Second, MaxWait: 2 * time.Second}
+
+	for i := 0; i < 100; i++ {
+		if w := c.RandomWait(); w < c.MinWait || w > c.MaxWait {
+			panic(fmt.Errorf("(ConsensusConfig) RandomWait() out of range: %v", w))
+		}
+	}
+
+	if w := (ConsensusConfig{}).RandomWait(); w != 0 {
+		panic(fmt.Errorf("(ConsensusConfig) RandomWait() should be zero for zero value"))
+	}
+
+	// Block producer never spins without timing rules.
+	if w := (ConsensusConfig{}).produceWait(); w != MinProducePeriod {
+		panic(fmt.Errorf("(ConsensusConfig) produceWait() should be MinProducePeriod for zero value: %v", w))
+	}
+
+	if w := c.produceWait(); w < c.MinWait || w > c.MaxWait {
+		panic(fmt.Errorf("(ConsensusConfig) produceWait() should follow timing rules: %v", w))
+	}
+}
+
+// Test timing rules of block generation.
+func TestBlockTiming(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	n.Consensus.MinSpacing = time.Hour
+
+	n.CheckAndAddTransactionToPool(n.NewGenesisTransaction([]byte("genesis")))
+
+	if b, _ := n.ProduceBlock(); !b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() testing failed"))
+	}
+
+	m, _ := NewNode("127.0.0.1", 0)
+	n.CheckAndAddTransactionToPool(m.NewGenesisTransaction([]byte("genesis")))
+
+	if b, _ := n.ProduceBlock(); b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should keep minimum spacing"))
+	}
+
+	// Other generator's block is checked against its own previous block.
+	_, last := n.GetLastBlock()
+
+	next, _ := m.NewBlock(last.ID(), nil)
+	if err := n.CheckBlockTiming(next); err != nil {
+		panic(err)
+	}
+
+	again, _ := n.NewBlock(last.ID(), nil)
+	if err := n.CheckBlockTiming(again); err != ErrBlockTooSoon {
+		panic(fmt.Errorf("(*Node) CheckBlockTiming() should reject block produced too soon, got %v", err))
+	}
+
+	next.Header.Timestamp += 2 * BlockTimestampTolerance
+	next.Sign(m.KeyPair())
+
+	if err := n.CheckBlockTiming(next); err != ErrBlockFromFuture {
+		panic(fmt.Errorf("(*Node) CheckBlockTiming() should reject block from future, got %v", err))
+	}
+
+	// Chain validation applies the same rule over history.
+	g, bc := GenValidChain(2, 1)
+
+	if err := bc.ValidateTiming(0); err != nil {
+		panic(err)
+	}
+
+	g.Consensus.MinSpacing = time.Hour
+
+	var verr *ValidationError
+	if err := g.ValidateChain(); !errors.As(err, &verr) || verr.BlockIndex != 1 {
+		panic(fmt.Errorf("(*Node) ValidateChain() should reject generator producing too soon"))
+	}
+}
@@ -1,98 +1,430 @@
 package core
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
 	"math/big"
 )
 
-// KeyPair ...
+// KeyType ... Signature scheme of key, it leads encoded public keys and signatures.
+type KeyType byte
+
+// Key types.
+const (
+	KeyTypeECDSAP256 KeyType = 0x01 // ECDSA on P-256
+	KeyTypeEd25519   KeyType = 0x02 // Ed25519
+)
+
+// legacyECDSASize ... Size of ECDSA public key and signature without key type, as nodes encoded them before.
+const legacyECDSASize = 64
+
+// Errors of keys.
+var (
+	ErrUnknownKeyType = errors.New("Unknown key type")
+	ErrMalformedKey   = errors.New("Malformed key")
+)
+
+// SignatureScheme ... Algorithm of signing and verifying.
+// Keys and signatures it handles are raw, i.e. without key type.
+type SignatureScheme interface {
+	Type() KeyType                                     // Key type of scheme
+	Name() string                                      // Name of scheme, e.g. in options
+	PrivateKeySize() int                               // Size of raw private key
+	CheckPublic(public []byte) error                   // Check if raw public key is well-formed, e.g. on curve
+	GenerateKey() (public, private []byte, err error)  // Generate raw key pair
+	PublicOf(private []byte) ([]byte, error)           // Derive raw public key from raw private key
+	Sign(public, private, hash []byte) ([]byte, error) // Sign hash into raw signature
+	Verify(public, signature, hash []byte) bool        // Verify raw signature, malformed input is never valid
+}
+
+var signatureSchemes = make(map[KeyType]SignatureScheme)
+
+// RegisterSignatureScheme ... Register signature scheme by its key type.
+// NOTE: Encoded public keys and signatures should not be 64 bytes, which is reserved for ECDSA without key type.
+func RegisterSignatureScheme(s SignatureScheme) {
+	signatureSchemes[s.Type()] = s
+}
+
+func init() {
+	RegisterSignatureScheme(ecdsaP256Scheme{})
+	RegisterSignatureScheme(ed25519Scheme{})
+}
+
+// SignatureSchemeOf ... Get signature scheme of key type.
+func SignatureSchemeOf(t KeyType) (SignatureScheme, error) {
+	s, ok := signatureSchemes[t]
+	if !ok {
+		return nil, ErrUnknownKeyType
+	}
+
+	return s, nil
+}
+
+// ParseKeyType ... Get key type by name of its signature scheme.
+func ParseKeyType(name string) (KeyType, error) {
+	for t, s := range signatureSchemes {
+		if s.Name() == name {
+			return t, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown key type %q", name)
+}
+
+// String ... Name of key type.
+func (t KeyType) String() string {
+	if s, err := SignatureSchemeOf(t); err == nil {
+		return s.Name()
+	}
+
+	return fmt.Sprintf("unknown(%#x)", byte(t))
+}
+
+// decodeTagged ... Split encoded public key or signature into its signature scheme and raw bytes.
+// Bytes of legacy size without key type are ECDSA.
+func decodeTagged(b []byte) (SignatureScheme, []byte, error) {
+	if len(b) == legacyECDSASize {
+		return signatureSchemes[KeyTypeECDSAP256], b, nil
+	}
+
+	if len(b) < 2 {
+		return nil, nil, ErrMalformedKey
+	}
+
+	s, err := SignatureSchemeOf(KeyType(b[0]))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s, b[1:], nil
+}
+
+// encodeTagged ... Prefix raw public key or signature with key type.
+func encodeTagged(t KeyType, raw []byte) []byte {
+	return append([]byte{byte(t)}, raw...)
+}
+
+// KeyTypeOf ... Get key type of encoded public key.
+func KeyTypeOf(publicKey []byte) (KeyType, error) {
+	s, _, err := decodeTagged(publicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Type(), nil
+}
+
+// Signer ... Sign hashes as owner of public key.
+type Signer interface {
+	PublicKey() []byte                // Encoded public key
+	Sign(hash []byte) ([]byte, error) // Sign hash into encoded signature
+}
+
+// Verifier ... Verify signatures of public key.
+type Verifier interface {
+	Type() KeyType                      // Key type of public key
+	Verify(signature, hash []byte) bool // Verify encoded signature
+}
+
+// verifier ... Verifier of encoded public key.
+type verifier struct {
+	scheme SignatureScheme
+	public []byte
+}
+
+// NewVerifier ... Generate verifier of encoded public key, malformed public key is an error.
+func NewVerifier(publicKey []byte) (Verifier, error) {
+	s, raw, err := decodeTagged(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.CheckPublic(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifier{scheme: s, public: raw}, nil
+}
+
+// ValidatePublicKey ... Check if encoded public key is well-formed, e.g. one received from other nodes.
+func ValidatePublicKey(publicKey []byte) error {
+	_, err := NewVerifier(publicKey)
+	return err
+}
+
+// Type ... Implement Verifier.
+func (v verifier) Type() KeyType {
+	return v.scheme.Type()
+}
+
+// Verify ... Implement Verifier, signature should be of the same scheme as public key.
+func (v verifier) Verify(signature, hash []byte) bool {
+	s, raw, err := decodeTagged(signature)
+	if err != nil || s.Type() != v.scheme.Type() {
+		return false
+	}
+
+	return v.scheme.Verify(v.public, raw, hash)
+}
+
+// KeyPair ... Public key is encoded with its key type, private key is raw key of its scheme.
 type KeyPair struct {
 	Public  []byte
 	Private []byte
 }
 
+// NewKeyPair ... Generate key pair of given key type.
+func NewKeyPair(t KeyType) (*KeyPair, error) {
+	s, err := SignatureSchemeOf(t)
+	if err != nil {
+		return nil, err
+	}
+
+	public, private, err := s.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{Public: encodeTagged(t, public), Private: private}, nil
+}
+
 // NewECDSAKeyPair ... Generate ECDSA key pair
 // Public key format:
-// | x ... 32 bytes | y ... 32 bytes |
+// | key type | x ... 32 bytes | y ... 32 bytes |
 // Private key format:
 // | private key |
 func NewECDSAKeyPair() (*KeyPair, error) {
-	pubKeyCurve := elliptic.P256()
+	return NewKeyPair(KeyTypeECDSAP256)
+}
 
-	privateKey := new(ecdsa.PrivateKey)
-	privateKey, err := ecdsa.GenerateKey(pubKeyCurve, rand.Reader)
+// NewEd25519KeyPair ... Generate Ed25519 key pair
+// Public key format:
+// | key type | public key ... 32 bytes |
+// Private key format:
+// | seed ... 32 bytes |
+func NewEd25519KeyPair() (*KeyPair, error) {
+	return NewKeyPair(KeyTypeEd25519)
+}
+
+// Type ... Get key type of key pair.
+func (kp *KeyPair) Type() KeyType {
+	t, _ := KeyTypeOf(kp.Public)
+	return t
+}
+
+// PublicKey ... Implement Signer.
+func (kp *KeyPair) PublicKey() []byte {
+	return kp.Public
+}
+
+// Validate ... Test if public key is derived from private key.
+func (kp *KeyPair) Validate() error {
+	s, public, err := decodeTagged(kp.Public)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if len(kp.Private) != s.PrivateKeySize() {
+		return ErrMalformedKey
 	}
 
-	xy := privateKey.PublicKey
-	xBytes, err := BigIntToBytes(xy.X, 32)
+	derived, err := s.PublicOf(kp.Private)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(derived, public) {
+		return ErrKeyPairMismatch
+	}
+
+	return nil
+}
+
+// Sign ... Sign a hash of a file/message
+func (kp *KeyPair) Sign(hash []byte) ([]byte, error) {
+	s, public, err := decodeTagged(kp.Public)
 	if err != nil {
 		return nil, err
 	}
 
-	yBytes, err := BigIntToBytes(xy.Y, 32)
+	sig, err := s.Sign(public, kp.Private, hash)
 	if err != nil {
 		return nil, err
 	}
 
-	publicKeyBytes := append(xBytes, yBytes...)
+	return encodeTagged(s.Type(), sig), nil
+}
+
+// VerifySignature ... Verify signature
+func VerifySignature(publicKey, signature, hash []byte) bool {
+	v, err := NewVerifier(publicKey)
+	if err != nil {
+		return false
+	}
+
+	return v.Verify(signature, hash)
+}
+
+// ecdsaP256Scheme ... ECDSA on P-256, with RFC 6979 deterministic nonces and low-S signatures.
+// Public key format:
+// | x ... 32 bytes | y ... 32 bytes |
+// Private key format:
+// | d ... 32 bytes |
+// Signature format:
+// | r ... 32 bytes | s ... 32 bytes |, s <= N/2
+type ecdsaP256Scheme struct{}
+
+// Type ... Implement SignatureScheme.
+func (ecdsaP256Scheme) Type() KeyType { return KeyTypeECDSAP256 }
+
+// Name ... Implement SignatureScheme.
+func (ecdsaP256Scheme) Name() string { return "ecdsa" }
+
+// PrivateKeySize ... Implement SignatureScheme.
+func (ecdsaP256Scheme) PrivateKeySize() int { return 32 }
+
+// GenerateKey ... Implement SignatureScheme.
+func (ecdsaP256Scheme) GenerateKey() ([]byte, []byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKeyBytes, err := encodeECDSAPoint(privateKey.X, privateKey.Y)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	privateKeyBytes, err := BigIntToBytes(privateKey.D, 32)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &KeyPair{Public: publicKeyBytes, Private: privateKeyBytes}, nil
+	return publicKeyBytes, privateKeyBytes, nil
 }
 
-// Sign ... Sign a hash of a file/message
-func (kp *KeyPair) Sign(hash []byte) ([]byte, error) {
-	// Decode private key
-	privateKey := new(big.Int)
-	privateKey.SetBytes(kp.Private)
+// CheckPublic ... Implement SignatureScheme, public key should be on curve.
+func (ecdsaP256Scheme) CheckPublic(public []byte) error {
+	_, err := decodeECDSAPublic(public)
+	return err
+}
 
-	// Decode public key
-	publicKey := kp.Public
-	xBytes, yBytes := publicKey[:32], publicKey[32:]
-	x := BytesToBigInt(xBytes)
-	y := BytesToBigInt(yBytes)
+// PublicOf ... Implement SignatureScheme.
+func (ecdsaP256Scheme) PublicOf(private []byte) ([]byte, error) {
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), private)
+	if err != nil {
+		return nil, ErrMalformedKey
+	}
+
+	return encodeECDSAPoint(key.X, key.Y)
+}
+
+// Sign ... Implement SignatureScheme.
+// Nonce is derived from private key and hash (RFC 6979), so the same hash always gets the same signature.
+func (ecdsaP256Scheme) Sign(public, private, hash []byte) ([]byte, error) {
+	if len(hash) != sha256.Size {
+		return nil, errors.New("ECDSA signs SHA256 hash only")
+	}
 
-	key := ecdsa.PrivateKey{ecdsa.PublicKey{elliptic.P256(), x, y}, privateKey}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), private)
+	if err != nil {
+		return nil, ErrMalformedKey
+	}
 
-	r, s, err := ecdsa.Sign(rand.Reader, &key, hash)
+	derived, err := encodeECDSAPoint(key.X, key.Y)
+	if err != nil || !bytes.Equal(derived, public) {
+		return nil, ErrKeyPairMismatch
+	}
+
+	der, err := key.Sign(nil, hash, crypto.SHA256)
 	if err != nil {
 		return nil, err
 	}
 
-	rBytes, err := BigIntToBytes(r, 32)
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+
+	rBytes, err := BigIntToBytes(sig.R, 32)
 	if err != nil {
 		return nil, err
 	}
 
-	sBytes, err := BigIntToBytes(s, 32)
+	sBytes, err := BigIntToBytes(lowS(sig.S), 32)
 	if err != nil {
 		return nil, err
 	}
 
-	rs := append(rBytes, sBytes...)
+	return append(rBytes, sBytes...), nil
+}
+
+// Verify ... Implement SignatureScheme, signature with high S is rejected since it's malleable.
+func (ecdsaP256Scheme) Verify(public, signature, hash []byte) bool {
+	if len(signature) != 64 {
+		return false
+	}
+
+	pub, err := decodeECDSAPublic(public)
+	if err != nil {
+		return false
+	}
+
+	r := BytesToBigInt(signature[:32])
+	s := BytesToBigInt(signature[32:])
+
+	if s.Cmp(p256HalfOrder) > 0 {
+		return false
+	}
+
+	return ecdsa.Verify(pub, hash, r, s)
+}
+
+// p256HalfOrder ... N/2 of P-256, S above it is high.
+var p256HalfOrder = new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+
+// lowS ... Normalize S into low half, both (r, s) and (r, N - s) are valid signatures.
+func lowS(s *big.Int) *big.Int {
+	if s.Cmp(p256HalfOrder) > 0 {
+		return new(big.Int).Sub(elliptic.P256().Params().N, s)
+	}
 
-	return []byte(rs), nil
+	return s
 }
 
-// VerifySignature ... Verify signature
-func VerifySignature(publicKey, signature, hash []byte) bool {
-	xBytes, yBytes := publicKey[:32], publicKey[32:]
-	x := BytesToBigInt(xBytes)
-	y := BytesToBigInt(yBytes)
+// decodeECDSAPublic ... Decode x || y into public key, the point should be on curve.
+func decodeECDSAPublic(public []byte) (*ecdsa.PublicKey, error) {
+	if len(public) != 64 {
+		return nil, ErrMalformedKey
+	}
+
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), JoinBytes([]byte{4}, public))
+	if err != nil {
+		return nil, ErrMalformedKey
+	}
 
-	rBytes, sBytes := signature[:32], signature[32:]
-	r := BytesToBigInt(rBytes)
-	s := BytesToBigInt(sBytes)
+	return pub, nil
+}
+
+// encodeECDSAPoint ... Encode point into x || y.
+func encodeECDSAPoint(x, y *big.Int) ([]byte, error) {
+	xBytes, err := BigIntToBytes(x, 32)
+	if err != nil {
+		return nil, err
+	}
 
-	pub := ecdsa.PublicKey{elliptic.P256(), x, y}
+	yBytes, err := BigIntToBytes(y, 32)
+	if err != nil {
+		return nil, err
+	}
 
-	return ecdsa.Verify(&pub, hash, r, s)
+	return append(xBytes, yBytes...), nil
 }
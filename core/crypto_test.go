@@ -1,7 +1,10 @@
 package core
 
 import (
+	"bytes"
+	"crypto/elliptic"
 	"fmt"
+	"math/big"
 	"testing"
 )
 
@@ -11,7 +14,7 @@ func TestKeyGen(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
-		if len(keyPair.Public) != 64 || len(keyPair.Private) != 32 {
+		if len(keyPair.Public) != 65 || len(keyPair.Private) != 32 || keyPair.Type() != KeyTypeECDSAP256 {
 			panic(fmt.Errorf("Invalid key pair"))
 		}
 	}
@@ -35,3 +38,142 @@ func TestKeySigningAndVerify(t *testing.T) {
 		}
 	}
 }
+
+// Test signing with every signature scheme, signatures describe their scheme.
+func TestSignatureSchemes(t *testing.T) {
+	hash := SHA256([]byte("test"))
+
+	ecdsaKP, _ := NewECDSAKeyPair()
+	ed25519KP, err := NewEd25519KeyPair()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(ed25519KP.Public) != 33 || ed25519KP.Type() != KeyTypeEd25519 {
+		panic(fmt.Errorf("Invalid Ed25519 key pair"))
+	}
+
+	for _, kp := range []*KeyPair{ecdsaKP, ed25519KP} {
+		if err := kp.Validate(); err != nil {
+			panic(err)
+		}
+
+		sig, err := kp.Sign(hash)
+		if err != nil {
+			panic(err)
+		}
+
+		if KeyType(sig[0]) != kp.Type() || !VerifySignature(kp.Public, sig, hash) {
+			panic(fmt.Errorf("Signature of %s testing failed", kp.Type()))
+		}
+
+		if VerifySignature(kp.Public, sig, SHA256([]byte("other"))) {
+			panic(fmt.Errorf("Signature of %s should not verify other hash", kp.Type()))
+		}
+	}
+
+	// Signature is never verified by key of another scheme.
+	sig, _ := ed25519KP.Sign(hash)
+	if VerifySignature(ecdsaKP.Public, sig, hash) {
+		panic(fmt.Errorf("Signature should not be verified by key of another scheme"))
+	}
+
+	if kt, err := ParseKeyType("ed25519"); err != nil || kt != KeyTypeEd25519 {
+		panic(fmt.Errorf("ParseKeyType() testing failed"))
+	}
+}
+
+// Test keys and signatures of ECDSA without key type, which nodes encoded before.
+func TestLegacyECDSAKey(t *testing.T) {
+	hash := SHA256([]byte("test"))
+
+	kp, _ := NewECDSAKeyPair()
+	legacy := &KeyPair{Public: kp.Public[1:], Private: kp.Private}
+
+	if legacy.Type() != KeyTypeECDSAP256 || legacy.Validate() != nil {
+		panic(fmt.Errorf("Legacy ECDSA key pair should be valid"))
+	}
+
+	sig, _ := kp.Sign(hash)
+
+	if !VerifySignature(legacy.Public, sig, hash) || !VerifySignature(kp.Public, sig[1:], hash) {
+		panic(fmt.Errorf("Legacy ECDSA key and signature should be verified"))
+	}
+}
+
+// Test ECDSA signatures are deterministic and low S, and high S signatures are refused.
+func TestECDSASignatureMalleability(t *testing.T) {
+	hash := SHA256([]byte("test"))
+
+	kp, _ := NewECDSAKeyPair()
+
+	sig1, _ := kp.Sign(hash)
+	sig2, _ := kp.Sign(hash)
+
+	if !bytes.Equal(sig1, sig2) {
+		panic(fmt.Errorf("ECDSA signature should be deterministic"))
+	}
+
+	n := elliptic.P256().Params().N
+	s := new(big.Int).SetBytes(sig1[33:])
+
+	if s.Cmp(p256HalfOrder) > 0 {
+		panic(fmt.Errorf("ECDSA signature should be low S"))
+	}
+
+	high := make([]byte, len(sig1))
+	copy(high, sig1)
+	new(big.Int).Sub(n, s).FillBytes(high[33:])
+
+	// High S is refused with or without key type.
+	if VerifySignature(kp.Public, high, hash) || VerifySignature(kp.Public, high[1:], hash) {
+		panic(fmt.Errorf("ECDSA signature with high S should be refused"))
+	}
+
+	if _, err := kp.Sign(hash[:16]); err == nil {
+		panic(fmt.Errorf("ECDSA should refuse to sign hash of wrong size"))
+	}
+}
+
+// Test malformed keys and signatures are refused without panic.
+func TestMalformedKeysAndSignatures(t *testing.T) {
+	hash := SHA256([]byte("test"))
+
+	kp, _ := NewECDSAKeyPair()
+	sig, _ := kp.Sign(hash)
+
+	// Point which is not on curve.
+	offCurve := make([]byte, len(kp.Public))
+	copy(offCurve, kp.Public)
+	offCurve[len(offCurve)-1] ^= 0x01
+
+	keys := [][]byte{nil, {}, {byte(KeyTypeECDSAP256)}, {0xff, 0x01}, kp.Public[:40], offCurve,
+		GenRandomBytes(64), append([]byte{byte(KeyTypeEd25519)}, GenRandomBytes(31)...)}
+
+	for _, key := range keys {
+		if ValidatePublicKey(key) == nil {
+			panic(fmt.Errorf("ValidatePublicKey() should refuse malformed key %x", key))
+		}
+
+		if _, err := NewVerifier(key); err == nil {
+			panic(fmt.Errorf("NewVerifier() should refuse malformed key %x", key))
+		}
+
+		if VerifySignature(key, sig, hash) {
+			panic(fmt.Errorf("VerifySignature() should refuse malformed key %x", key))
+		}
+	}
+
+	sigs := [][]byte{nil, {}, {byte(KeyTypeECDSAP256)}, sig[:20], append(sig, 0x00), make([]byte, len(sig))}
+
+	for _, s := range sigs {
+		if VerifySignature(kp.Public, s, hash) {
+			panic(fmt.Errorf("VerifySignature() should refuse malformed signature %x", s))
+		}
+	}
+
+	m := Message{Sender: offCurve, Signature: sig}
+	if m.VerifySignature() {
+		panic(fmt.Errorf("(Message) VerifySignature() should refuse malformed sender"))
+	}
+}
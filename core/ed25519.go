@@ -0,0 +1,70 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+)
+
+// ed25519Scheme ... Ed25519, it's much cheaper than ECDSA on small devices.
+// Public key format:
+// | public key ... 32 bytes |
+// Private key format:
+// | seed ... 32 bytes |
+// Signature format:
+// | signature ... 64 bytes |
+type ed25519Scheme struct{}
+
+// Type ... Implement SignatureScheme.
+func (ed25519Scheme) Type() KeyType { return KeyTypeEd25519 }
+
+// Name ... Implement SignatureScheme.
+func (ed25519Scheme) Name() string { return "ed25519" }
+
+// PrivateKeySize ... Implement SignatureScheme.
+func (ed25519Scheme) PrivateKeySize() int { return ed25519.SeedSize }
+
+// GenerateKey ... Implement SignatureScheme.
+func (ed25519Scheme) GenerateKey() ([]byte, []byte, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return public, private.Seed(), nil
+}
+
+// CheckPublic ... Implement SignatureScheme.
+func (ed25519Scheme) CheckPublic(public []byte) error {
+	if len(public) != ed25519.PublicKeySize {
+		return ErrMalformedKey
+	}
+
+	return nil
+}
+
+// PublicOf ... Implement SignatureScheme.
+func (ed25519Scheme) PublicOf(private []byte) ([]byte, error) {
+	if len(private) != ed25519.SeedSize {
+		return nil, ErrMalformedKey
+	}
+
+	return ed25519.NewKeyFromSeed(private).Public().(ed25519.PublicKey), nil
+}
+
+// Sign ... Implement SignatureScheme.
+func (ed25519Scheme) Sign(public, private, hash []byte) ([]byte, error) {
+	if len(public) != ed25519.PublicKeySize || len(private) != ed25519.SeedSize {
+		return nil, ErrMalformedKey
+	}
+
+	return ed25519.Sign(ed25519.NewKeyFromSeed(private), hash), nil
+}
+
+// Verify ... Implement SignatureScheme.
+func (ed25519Scheme) Verify(public, signature, hash []byte) bool {
+	if len(public) != ed25519.PublicKeySize || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+
+	return ed25519.Verify(public, hash, signature)
+}
@@ -0,0 +1,54 @@
+package core
+
+// ForkChoice ... Rule to pick main chain among branches of block tree.
+// Block extending tip of main chain always extends it, rule is only asked about other branches.
+type ForkChoice interface {
+	// Prefer ... Test if candidate branch should replace current main chain.
+	Prefer(candidate, current Blockchain) bool
+}
+
+// ForkChoiceFunc ... Function as fork choice rule.
+type ForkChoiceFunc func(candidate, current Blockchain) bool
+
+// Prefer ... Implement ForkChoice interface.
+func (f ForkChoiceFunc) Prefer(candidate, current Blockchain) bool {
+	return f(candidate, current)
+}
+
+// LongestChain ... Prefer longer chain, current main chain wins ties.
+var LongestChain = ForkChoiceFunc(func(candidate, current Blockchain) bool {
+	return candidate.Height() > current.Height()
+})
+
+// MostTrustedChain ... Prefer chain whose generators are more trusted, and the longer one if they're equally trusted.
+// Every block weighs the reputation score of its generator, scores are derived from current main chain,
+// so candidate branch could not vouch for its own generators. Blocks both chains share weigh the same,
+// so only blocks after fork are weighed.
+var MostTrustedChain = ForkChoiceFunc(func(candidate, current Blockchain) bool {
+	reputations := trustedReputations.of(current)
+	fork := forkHeight(candidate, current)
+
+	weight := func(bc Blockchain) float64 {
+		var w float64
+
+		for _, b := range bc.Blocks[fork:] {
+			if r, ok := reputations[Base58Encode(b.Header.GeneratorID)]; ok {
+				w += r.Score
+			} else {
+				w += NewReputation(b.Header.GeneratorID, 0, 0, 0).Score
+			}
+		}
+
+		return w
+	}
+
+	wc, wr := weight(candidate), weight(current)
+	if wc != wr {
+		return wc > wr
+	}
+
+	return LongestChain(candidate, current)
+})
+
+// trustedReputations ... Reputations of the last main chain MostTrustedChain weighed branches against.
+var trustedReputations reputationCache
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// FrameHeaderSize ... Size of frame header.
+	FrameHeaderSize = 4
+
+	// MaxFrameSize ... Max payload length of one frame.
+	MaxFrameSize = 16 << 20
+
+	// MaxHandshakeFrameSize ... Max payload length of one frame of handshake, peer is not authenticated yet.
+	MaxHandshakeFrameSize = 1 << 10
+)
+
+// WriteFrame ... Write data as one frame.
+// Frame format:
+// | length of data ... 4 bytes | data ... length bytes |
+func WriteFrame(w io.Writer, data []byte) error {
+	if len(data) > MaxFrameSize {
+		return fmt.Errorf("Frame of %d bytes exceeds max frame size", len(data))
+	}
+
+	_, err := w.Write(JoinBytes(UInt32ToBytes(uint32(len(data))), data))
+
+	return err
+}
+
+// ReadFrame ... Read one complete frame, and return its data.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	return ReadLimitedFrame(r, MaxFrameSize)
+}
+
+// ReadLimitedFrame ... Read one complete frame whose data is at most max bytes, and return its data.
+func ReadLimitedFrame(r io.Reader, max uint32) ([]byte, error) {
+	header := make([]byte, FrameHeaderSize)
+
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header)
+	if length > max {
+		return nil, fmt.Errorf("Frame of %d bytes exceeds max frame size", length)
+	}
+
+	data := make([]byte, length)
+
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
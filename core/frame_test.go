@@ -0,0 +1,82 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+)
+
+// Test frame round trip.
+func TestReadWriteFrame(t *testing.T) {
+	var buf bytes.Buffer
+
+	data := GenRandomBytes(100 * 1024)
+
+	if err := WriteFrame(&buf, data); err != nil {
+		panic(err)
+	}
+
+	r, err := ReadFrame(&buf)
+	if err != nil || !bytes.Equal(data, r) {
+		panic(fmt.Errorf("ReadFrame()/WriteFrame() testing failed"))
+	}
+
+	// Truncated frame.
+	_ = WriteFrame(&buf, data)
+	buf.Truncate(buf.Len() - 1)
+
+	if _, err := ReadFrame(&buf); err == nil {
+		panic(fmt.Errorf("ReadFrame() should fail on truncated frame"))
+	}
+
+	// Oversized frame.
+	buf.Reset()
+	buf.Write(UInt32ToBytes(MaxFrameSize + 1))
+
+	if _, err := ReadFrame(&buf); err == nil {
+		panic(fmt.Errorf("ReadFrame() should reject oversized frame"))
+	}
+}
+
+// Test sending message larger than one TCP read.
+func TestSendLargeMessage(t *testing.T) {
+	n, err := NewNode("127.0.0.1", 0)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := n.Run(); err != nil {
+		panic(err)
+	}
+	defer n.Listerner.Close()
+
+	m := NewSyncTransactionsMessage(GenRandomTransactionSlice(500))
+	mjson, _ := m.MarshalJson()
+
+	go func() {
+		im := <-n.MessageChannel
+
+		if !im.Content.EqualWith(m) {
+			im.Reply([]byte("corrupted"))
+		} else {
+			im.Reply([]byte("ok"))
+		}
+
+		im.Close()
+	}()
+
+	addr := n.Listerner.Addr().(*net.TCPAddr).String()
+
+	err = n.Send(addr, mjson, func(resp []byte) error {
+		if string(resp) != "ok" {
+			return fmt.Errorf("Unexpected response: %s", resp)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Handshake of peer connection, it proves both peers possess private keys of their
+// public keys, and derives session keys from ephemeral ECDH on P-256:
+//
+//	initiator -> responder : hello(public key, ephemeral key, nonce)
+//	responder -> initiator : hello(public key, ephemeral key, nonce)
+//	initiator -> responder : Sign(SHA256("initiator" | transcript))
+//	responder -> initiator : Sign(SHA256("responder" | transcript))
+//
+// transcript is SHA256 of both hellos. Every later frame is sealed by AES-256-GCM,
+// each direction has its own key and a counter as nonce.
+
+const (
+	handshakeNonceSize = 32
+
+	initiatorLabel = "initiator"
+	responderLabel = "responder"
+)
+
+// KeyMismatchError ... Public key of peer is different from the one we expect.
+type KeyMismatchError struct {
+	Address  string // Address of peer
+	Expected []byte // Expected public key
+	Got      []byte // Public key proved by peer
+}
+
+// Error ... Implement error interface.
+func (e *KeyMismatchError) Error() string {
+	return fmt.Sprintf("%s proved public key %s, but %s is expected", e.Address, Base58Encode(e.Got), Base58Encode(e.Expected))
+}
+
+// session ... Keys of authenticated session.
+type session struct {
+	remotePK []byte
+	send     cipher.AEAD
+	recv     cipher.AEAD
+}
+
+// newAEAD ... Generate AES-256-GCM with given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sequenceNonce ... GCM nonce of given sequence number.
+func sequenceNonce(seq uint64) []byte {
+	return JoinBytes(make([]byte, 4), UInt64ToBytes(seq))
+}
+
+// handshake ... Run handshake on connection, expected is public key that peer should prove, nil to accept any.
+func handshake(conn net.Conn, kp *KeyPair, initiator bool, expected []byte) (*session, error) {
+	conn.SetDeadline(time.Now().Add(RequestTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	eph, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := GenSecureRandomBytes(handshakeNonceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	hello := JoinBytesWithLength(kp.Public, eph.PublicKey().Bytes(), nonce)
+
+	var remoteHello []byte
+
+	if initiator {
+		err = WriteFrame(conn, hello)
+		if err == nil {
+			remoteHello, err = ReadLimitedFrame(conn, MaxHandshakeFrameSize)
+		}
+	} else {
+		remoteHello, err = ReadLimitedFrame(conn, MaxHandshakeFrameSize)
+		if err == nil {
+			err = WriteFrame(conn, hello)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := SplitBytesWithLength(remoteHello)
+	if err != nil || len(fields) != 3 || len(fields[2]) != handshakeNonceSize {
+		return nil, errors.New("Malformed handshake hello")
+	}
+
+	if err := ValidatePublicKey(fields[0]); err != nil {
+		return nil, errors.New("Malformed handshake hello")
+	}
+
+	remotePK := fields[0]
+
+	if expected != nil && !bytes.Equal(remotePK, expected) {
+		return nil, &KeyMismatchError{Address: conn.RemoteAddr().String(), Expected: expected, Got: remotePK}
+	}
+
+	remoteEph, err := ecdh.P256().NewPublicKey(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var transcript []byte
+	localLabel, remoteLabel := initiatorLabel, responderLabel
+
+	if initiator {
+		transcript = SHA256(JoinBytes(hello, remoteHello))
+	} else {
+		transcript = SHA256(JoinBytes(remoteHello, hello))
+		localLabel, remoteLabel = remoteLabel, localLabel
+	}
+
+	sig, err := kp.Sign(SHA256(JoinBytes([]byte(localLabel), transcript)))
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func() error {
+		remoteSig, err := ReadLimitedFrame(conn, MaxHandshakeFrameSize)
+		if err != nil {
+			return err
+		}
+
+		if !VerifySignature(remotePK, remoteSig, SHA256(JoinBytes([]byte(remoteLabel), transcript))) {
+			return errors.New("Peer failed to prove possession of its private key")
+		}
+
+		return nil
+	}
+
+	// Responder doesn't prove itself until initiator does.
+	if initiator {
+		err = WriteFrame(conn, sig)
+		if err == nil {
+			err = verify()
+		}
+	} else {
+		err = verify()
+		if err == nil {
+			err = WriteFrame(conn, sig)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := eph.ECDH(remoteEph)
+	if err != nil {
+		return nil, err
+	}
+
+	send, err := newAEAD(SHA256(JoinBytes(shared, transcript, []byte(localLabel))))
+	if err != nil {
+		return nil, err
+	}
+
+	recv, err := newAEAD(SHA256(JoinBytes(shared, transcript, []byte(remoteLabel))))
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{remotePK: remotePK, send: send, recv: recv}, nil
+}
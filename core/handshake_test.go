@@ -0,0 +1,94 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+// Test authenticated transport between nodes.
+func TestHandshake(t *testing.T) {
+	server, addr := RunEchoNode()
+	defer server.Listerner.Close()
+
+	client, _ := NewNode("127.0.0.1", 0)
+	defer client.Peers.Close()
+
+	pc, _, err := client.Peers.Get(addr)
+	if err != nil {
+		panic(err)
+	}
+
+	if !bytes.Equal(pc.RemotePublicKey(), server.PublicKey()) {
+		panic(fmt.Errorf("handshake() should prove public key of server"))
+	}
+
+	client.Peers.Close()
+
+	// Nodes of different signature schemes talk to each other.
+	kp, _ := NewEd25519KeyPair()
+	client.SetKeyPair(kp)
+
+	if err := client.Send(addr, []byte("{}"), func([]byte) error { return nil }); err != nil {
+		panic(fmt.Errorf("handshake() with Ed25519 key testing failed: %v", err))
+	}
+
+	client.Peers.Close()
+
+	// Routing table expects another key at this address.
+	impostor := GenRandomRemoteNode()
+	impostor.Address = addr
+	client.CheckAndAddNodeToRoutingTable(impostor)
+
+	var kerr *KeyMismatchError
+
+	err = client.Send(addr, []byte("{}"), func([]byte) error { return nil })
+	if !errors.As(err, &kerr) || !bytes.Equal(kerr.Got, server.PublicKey()) {
+		panic(fmt.Errorf("(*Node) Send() should fail on key mismatch, got %v", err))
+	}
+}
+
+// Test peer that doesn't possess private key of its public key.
+func TestHandshakeWithForgedKey(t *testing.T) {
+	kp, _ := NewECDSAKeyPair()
+	victim, _ := NewECDSAKeyPair()
+
+	forged := &KeyPair{Public: victim.Public, Private: kp.Private}
+
+	c1, c2 := net.Pipe()
+
+	errs := make(chan error, 1)
+
+	go func() {
+		_, err := handshake(c2, kp, false, nil)
+		c2.Close()
+		errs <- err
+	}()
+
+	_, err := handshake(c1, forged, true, nil)
+	c1.Close()
+
+	if <-errs == nil || err == nil {
+		panic(fmt.Errorf("handshake() accepted forged key"))
+	}
+}
+
+// Test peer announcing hello larger than handshake allows.
+func TestHandshakeWithOversizedHello(t *testing.T) {
+	kp, _ := NewECDSAKeyPair()
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+
+	go c1.Write(UInt32ToBytes(MaxHandshakeFrameSize + 1))
+
+	// Rejected by header alone, data of frame is never read.
+	_, err := handshake(c2, kp, false, nil)
+	c2.Close()
+
+	if err == nil {
+		panic(fmt.Errorf("handshake() should reject oversized hello"))
+	}
+}
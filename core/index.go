@@ -0,0 +1,253 @@
+package core
+
+import (
+	"sort"
+)
+
+// TransactionLocation ... Position of transaction in chain.
+type TransactionLocation struct {
+	Height int // Index of block in chain
+	Index  int // Index of transaction in block
+}
+
+// timeEntry ... Block timestamp in time index.
+type timeEntry struct {
+	Timestamp int // Timestamp of block
+	Height    int // Index of block in chain
+}
+
+// ChainIndex ... Index of main chain, maintained alongside it, so lookups don't scan every block.
+// Transactions are indexed by id, previous transaction id, requester and requestee,
+// and blocks by id, timestamp and generator. Blocks are only added to or removed from the end of chain.
+type ChainIndex struct {
+	byID        map[string]TransactionLocation   // Transaction by id
+	byPrevID    map[string]TransactionLocation   // Non-genesis transaction by previous transaction id
+	byRequester map[string][]TransactionLocation // Transactions of requester, in chain order
+	byRequestee map[string][]TransactionLocation // Transactions of requestee, in chain order
+	byBlockID   map[string]int                   // Height of block by id
+	byTime      []timeEntry                      // Blocks sorted by timestamp, then height
+	byGenerator map[string]int                   // Number of blocks of generator
+}
+
+// NewChainIndex ... Generate index of empty chain.
+func NewChainIndex() *ChainIndex {
+	return &ChainIndex{
+		byID:        make(map[string]TransactionLocation),
+		byPrevID:    make(map[string]TransactionLocation),
+		byRequester: make(map[string][]TransactionLocation),
+		byRequestee: make(map[string][]TransactionLocation),
+		byBlockID:   make(map[string]int),
+		byGenerator: make(map[string]int),
+	}
+}
+
+// searchTime ... Get position of the first block in time index not earlier than given entry.
+func (ci *ChainIndex) searchTime(e timeEntry) int {
+	return sort.Search(len(ci.byTime), func(i int) bool {
+		t := ci.byTime[i]
+		return t.Timestamp > e.Timestamp || (t.Timestamp == e.Timestamp && t.Height >= e.Height)
+	})
+}
+
+// AddBlock ... Index block appended to chain at given height.
+func (ci *ChainIndex) AddBlock(height int, b Block) {
+	ci.byBlockID[Base58Encode(b.ID())] = height
+	ci.byGenerator[Base58Encode(b.Header.GeneratorID)]++
+
+	for i, t := range b.Transactions {
+		loc := TransactionLocation{height, i}
+
+		ci.byID[Base58Encode(t.ID())] = loc
+
+		if !t.IsGenesisTransaction() {
+			ci.byPrevID[Base58Encode(t.PreviousID())] = loc
+		}
+
+		requester, requestee := Base58Encode(t.RequesterPK()), Base58Encode(t.RequesteePK())
+		ci.byRequester[requester] = append(ci.byRequester[requester], loc)
+		ci.byRequestee[requestee] = append(ci.byRequestee[requestee], loc)
+	}
+
+	// Blocks mostly come in time order, so it's appended in most cases.
+	e := timeEntry{b.Header.Timestamp, height}
+	i := ci.searchTime(e)
+
+	ci.byTime = append(ci.byTime, timeEntry{})
+	copy(ci.byTime[i+1:], ci.byTime[i:])
+	ci.byTime[i] = e
+}
+
+// RemoveBlock ... Remove block at given height, which is the last block of chain, from index.
+func (ci *ChainIndex) RemoveBlock(height int, b Block) {
+	delete(ci.byBlockID, Base58Encode(b.ID()))
+
+	generator := Base58Encode(b.Header.GeneratorID)
+	if ci.byGenerator[generator]--; ci.byGenerator[generator] == 0 {
+		delete(ci.byGenerator, generator)
+	}
+
+	for _, t := range b.Transactions {
+		delete(ci.byID, Base58Encode(t.ID()))
+
+		if !t.IsGenesisTransaction() {
+			delete(ci.byPrevID, Base58Encode(t.PreviousID()))
+		}
+
+		requester, requestee := Base58Encode(t.RequesterPK()), Base58Encode(t.RequesteePK())
+		ci.byRequester[requester] = dropLocationsFrom(ci.byRequester[requester], height)
+		ci.byRequestee[requestee] = dropLocationsFrom(ci.byRequestee[requestee], height)
+
+		if len(ci.byRequester[requester]) == 0 {
+			delete(ci.byRequester, requester)
+		}
+
+		if len(ci.byRequestee[requestee]) == 0 {
+			delete(ci.byRequestee, requestee)
+		}
+	}
+
+	e := timeEntry{b.Header.Timestamp, height}
+	if i := ci.searchTime(e); i < len(ci.byTime) && ci.byTime[i] == e {
+		ci.byTime = append(ci.byTime[:i], ci.byTime[i+1:]...)
+	}
+}
+
+// dropLocationsFrom ... Drop trailing locations in blocks from given height on.
+func dropLocationsFrom(locs []TransactionLocation, height int) []TransactionLocation {
+	for len(locs) > 0 && locs[len(locs)-1].Height >= height {
+		locs = locs[:len(locs)-1]
+	}
+
+	return locs
+}
+
+// LocateTransaction ... Get position of transaction with given id.
+func (ci *ChainIndex) LocateTransaction(id []byte) (bool, TransactionLocation) {
+	loc, ok := ci.byID[Base58Encode(id)]
+	return ok, loc
+}
+
+// LocateTransactionByPrevID ... Get position of non-genesis transaction which claims given previous transaction.
+func (ci *ChainIndex) LocateTransactionByPrevID(prevID []byte) (bool, TransactionLocation) {
+	loc, ok := ci.byPrevID[Base58Encode(prevID)]
+	return ok, loc
+}
+
+// LocateTransactionsByRequester ... Get positions of transactions of requester, in chain order.
+func (ci *ChainIndex) LocateTransactionsByRequester(pk []byte) []TransactionLocation {
+	return ci.byRequester[Base58Encode(pk)]
+}
+
+// LocateTransactionsByRequestee ... Get positions of transactions of requestee, in chain order.
+func (ci *ChainIndex) LocateTransactionsByRequestee(pk []byte) []TransactionLocation {
+	return ci.byRequestee[Base58Encode(pk)]
+}
+
+// LocateBlock ... Get height of block with given id.
+func (ci *ChainIndex) LocateBlock(id []byte) (bool, int) {
+	height, ok := ci.byBlockID[Base58Encode(id)]
+	return ok, height
+}
+
+// LocateBlocksByTime ... Get heights of blocks with timestamps in [from, to], in time order.
+func (ci *ChainIndex) LocateBlocksByTime(from, to int) []int {
+	var heights []int
+
+	for i := ci.searchTime(timeEntry{from, 0}); i < len(ci.byTime) && ci.byTime[i].Timestamp <= to; i++ {
+		heights = append(heights, ci.byTime[i].Height)
+	}
+
+	return heights
+}
+
+// Generators ... Get public keys of generators of blocks in chain.
+func (ci *ChainIndex) Generators() [][]byte {
+	var generators [][]byte
+
+	for generator := range ci.byGenerator {
+		generators = append(generators, Base58Decode(generator))
+	}
+
+	return generators
+}
+
+// transactionAt ... Get transaction at given position of chain.
+// NOTE: Caller should hold chain lock.
+func (n *Node) transactionAt(loc TransactionLocation) Transaction {
+	return n.Chain.Blocks[loc.Height].Transactions[loc.Index]
+}
+
+// transactionsAt ... Get transactions at given positions of chain.
+// NOTE: Caller should hold chain lock.
+func (n *Node) transactionsAt(locs []TransactionLocation) TransactionSlice {
+	var trs TransactionSlice
+
+	for _, loc := range locs {
+		trs = append(trs, n.transactionAt(loc))
+	}
+
+	return trs
+}
+
+// indexBlocks ... Index blocks appended to chain from given height on.
+// NOTE: Caller should hold chain lock.
+func (n *Node) indexBlocks(from int) {
+	for i := from; i < n.Chain.Height(); i++ {
+		n.Index.AddBlock(i, n.Chain.Blocks[i])
+	}
+}
+
+// GetTransactionsByRequester ... Get transactions of requester in chain, in chain order.
+func (n *Node) GetTransactionsByRequester(pk []byte) TransactionSlice {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.transactionsAt(n.Index.LocateTransactionsByRequester(pk))
+}
+
+// GetTransactionsByRequestee ... Get transactions of requestee in chain, in chain order.
+func (n *Node) GetTransactionsByRequestee(pk []byte) TransactionSlice {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.transactionsAt(n.Index.LocateTransactionsByRequestee(pk))
+}
+
+// GetBlockByHeight ... Get block of chain at given height.
+func (n *Node) GetBlockByHeight(height int) (bool, Block) {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	if height < 0 || height >= n.Chain.Height() {
+		return false, Block{}
+	}
+
+	return true, n.Chain.Blocks[height]
+}
+
+// GetBlockByID ... Get block of chain with given id.
+func (n *Node) GetBlockByID(id []byte) (bool, Block) {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	found, height := n.Index.LocateBlock(id)
+	if !found {
+		return false, Block{}
+	}
+
+	return true, n.Chain.Blocks[height]
+}
+
+// GetBlocksByTime ... Get blocks of chain with timestamps in [from, to], in time order.
+func (n *Node) GetBlocksByTime(from, to int) BlockSlice {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	var bs BlockSlice
+
+	for _, height := range n.Index.LocateBlocksByTime(from, to) {
+		bs = append(bs, n.Chain.Blocks[height])
+	}
+
+	return bs
+}
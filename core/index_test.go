@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Check index of node against its chain.
+func CheckChainIndex(n *Node) {
+	count := 0
+	generators := make(map[string]int)
+
+	for h, b := range n.Chain.Blocks {
+		generators[Base58Encode(b.Header.GeneratorID)]++
+
+		if found, height := n.Index.LocateBlock(b.ID()); !found || height != h {
+			panic(fmt.Errorf("(*ChainIndex) LocateBlock() testing failed"))
+		}
+
+		for i, t := range b.Transactions {
+			if found, loc := n.Index.LocateTransaction(t.ID()); !found || loc.Height != h || loc.Index != i {
+				panic(fmt.Errorf("(*ChainIndex) LocateTransaction() testing failed"))
+			}
+
+			count++
+		}
+	}
+
+	if len(n.Index.byID) != count || len(n.Index.byTime) != n.Chain.Height() {
+		panic(fmt.Errorf("ChainIndex should index exactly blocks of chain"))
+	}
+
+	if len(n.Index.Generators()) != len(generators) {
+		panic(fmt.Errorf("(*ChainIndex) Generators() testing failed"))
+	}
+
+	for generator, blocks := range generators {
+		if n.Index.byGenerator[generator] != blocks {
+			panic(fmt.Errorf("ChainIndex should count blocks of generator"))
+		}
+	}
+}
+
+// Test indexed queries of chain.
+func TestChainIndex(t *testing.T) {
+	n, bc := GenValidChain(3, 2)
+
+	CheckChainIndex(n)
+
+	requester := bc.Blocks[0].Transactions[0].RequesterPK()
+	requestee := bc.Blocks[1].Transactions[0].RequesteePK()
+
+	if trs := n.GetTransactionsByRequester(requester); len(trs) != 7 || !trs[0].IsGenesisTransaction() {
+		panic(fmt.Errorf("(*Node) GetTransactionsByRequester() testing failed"))
+	}
+
+	if trs := n.GetTransactionsByRequestee(requestee); len(trs) != 6 {
+		panic(fmt.Errorf("(*Node) GetTransactionsByRequestee() testing failed"))
+	}
+
+	if b, block := n.GetBlockByHeight(1); !b || !bytes.Equal(block.ID(), bc.Blocks[1].ID()) {
+		panic(fmt.Errorf("(*Node) GetBlockByHeight() testing failed"))
+	}
+
+	if b, block := n.GetBlockByID(bc.Blocks[2].ID()); !b || !block.EqualWith(bc.Blocks[2]) {
+		panic(fmt.Errorf("(*Node) GetBlockByID() testing failed"))
+	}
+
+	first, last := bc.Blocks[0].Header.Timestamp, bc.Blocks[2].Header.Timestamp
+	if bs := n.GetBlocksByTime(first, last); len(bs) != 3 {
+		panic(fmt.Errorf("(*Node) GetBlocksByTime() testing failed"))
+	}
+
+	if bs := n.GetBlocksByTime(last+1, last+100); len(bs) != 0 {
+		panic(fmt.Errorf("(*Node) GetBlocksByTime() should be empty out of range"))
+	}
+
+	// Fork of sealed transaction is found through index.
+	t1 := bc.Blocks[2].Transactions[1]
+	t2 := GenSignedTransaction(n, n, bc.Blocks[2].Transactions[0], "fork")
+	if b, _ := n.Index.LocateTransactionByPrevID(t2.PreviousID()); !b || n.checkFork(t2) != ErrForkedTransaction {
+		panic(fmt.Errorf("(*Node) checkFork() should find transaction in chain"))
+	}
+
+	if b, tr := n.GetTransactionByIDFromChain(t1.ID()); !b || !tr.EqualWith(t1) {
+		panic(fmt.Errorf("(*Node) GetTransactionByIDFromChain() testing failed"))
+	}
+}
+
+// Test index follows reorganization of chain.
+func TestChainIndexReorg(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	m, _ := NewNode("127.0.0.1", 0)
+
+	gen := func(p *Node) {
+		x, _ := NewNode("127.0.0.1", 0)
+		p.CheckAndAddTransactionToPool(x.NewGenesisTransaction([]byte("genesis")))
+		p.ProduceBlock()
+	}
+
+	gen(n)
+	m.AddBlock(n.Chain.Blocks[0])
+
+	gen(n)
+	gen(m)
+	gen(m)
+
+	orphaned := n.Chain.Blocks[1]
+
+	if b, err := n.AddBlock(m.Chain.Blocks[1]); b || err != nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should keep main chain: %v", err))
+	}
+
+	if b, err := n.AddBlock(m.Chain.Blocks[2]); !b || err != nil {
+		panic(fmt.Errorf("(*Node) AddBlock() should switch chain: %v", err))
+	}
+
+	CheckChainIndex(n)
+
+	if b, _ := n.GetTransactionByIDFromChain(orphaned.Transactions[0].ID()); b {
+		panic(fmt.Errorf("ChainIndex should drop transactions of orphaned blocks"))
+	}
+
+	if trs := n.GetTransactionsByRequester(orphaned.Transactions[0].RequesterPK()); len(trs) != 0 {
+		panic(fmt.Errorf("ChainIndex should drop requester of orphaned blocks"))
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// NewJoinReply ... Generate reply to join message, with routing table and chain tip of node.
+// The joining node itself is excluded from routing table.
+func (n *Node) NewJoinReply(j JoinData) JoinReplyData {
+	_, nodes := n.GetNodesOfRoutingTable()
+
+	self := n.Self()
+
+	reply := JoinReplyData{
+		PublicKey: self.PublicKey,
+		Address:   self.Address,
+		Cluster:   self.Cluster,
+		Head:      self.Head,
+	}
+
+	for _, rn := range nodes {
+		if !bytes.Equal(rn.PublicKey, j.PublicKey) {
+			reply.Nodes = append(reply.Nodes, rn)
+		}
+	}
+
+	n.ChainLock.RLock()
+	reply.Height = n.Chain.Height()
+	if b, last := n.Chain.LastBlock(); b {
+		reply.TipID = last.ID()
+	}
+	n.ChainLock.RUnlock()
+
+	return reply
+}
+
+// AcceptJoin ... Add joining node into routing table, and generate reply to it.
+func (n *Node) AcceptJoin(j JoinData) JoinReplyData {
+	reply := n.NewJoinReply(j)
+
+	n.UpdateNodeForGivenPublicKey(j.PublicKey, RemoteNode{
+		PublicKey: j.PublicKey,
+		Address:   j.Address,
+		Lastseen:  int(time.Now().Unix()),
+		Cluster:   j.Cluster,
+		Head:      j.Head,
+	})
+
+	return reply
+}
+
+// Join ... Join network through bootstrap node.
+// Bootstrap node and its routing table are added into routing table of node.
+func (n *Node) Join(address string) (JoinReplyData, error) {
+	m := n.SignMessage(n.NewJoinMessage())
+
+	mjson, err := m.MarshalJson()
+	if err != nil {
+		return JoinReplyData{}, err
+	}
+
+	pc, _, err := n.Peers.Get(address)
+	if err != nil {
+		return JoinReplyData{}, err
+	}
+
+	var reply JoinReplyData
+
+	err = n.Send(address, mjson, func(data []byte) error {
+		return reply.UnmarshalJson(data)
+	})
+
+	if err != nil {
+		return JoinReplyData{}, err
+	}
+
+	if !bytes.Equal(reply.PublicKey, pc.RemotePublicKey()) {
+		return JoinReplyData{}, &KeyMismatchError{Address: address, Expected: pc.RemotePublicKey(), Got: reply.PublicKey}
+	}
+
+	if bytes.Equal(reply.PublicKey, n.PublicKey()) {
+		return JoinReplyData{}, errors.New("Cannot join network through node itself")
+	}
+
+	n.UpdateNodeForGivenPublicKey(reply.PublicKey, RemoteNode{
+		PublicKey: reply.PublicKey,
+		Address:   address,
+		Lastseen:  int(time.Now().Unix()),
+		Cluster:   reply.Cluster,
+		Head:      reply.Head,
+	})
+
+	for _, rn := range reply.Nodes {
+		if !bytes.Equal(rn.PublicKey, n.PublicKey()) {
+			n.CheckAndAddNodeToRoutingTable(rn)
+		}
+	}
+
+	return reply, nil
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+)
+
+// Test joining network through bootstrap node.
+func TestJoin(t *testing.T) {
+	bootstrap, err := NewNode("127.0.0.1", 0)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := bootstrap.Run(); err != nil {
+		panic(err)
+	}
+	defer bootstrap.Listerner.Close()
+
+	known := GenRandomRemoteNode()
+	bootstrap.CheckAndAddNodeToRoutingTable(known)
+
+	bootstrap.CheckAndAddTransactionToPool(bootstrap.NewGenesisTransaction([]byte("genesis")))
+	_, tip := bootstrap.ProduceBlock()
+
+	go func() {
+		for im := range bootstrap.MessageChannel {
+			var j JoinData
+
+			if im.Content.Type == Join && j.UnmarshalJson(im.Content.Data) == nil {
+				rjson, _ := bootstrap.AcceptJoin(j).MarshalJson()
+				im.Reply(rjson)
+			}
+
+			im.Close()
+		}
+	}()
+
+	n, _ := NewNode("127.0.0.1", 0)
+	defer n.Peers.Close()
+
+	bootstrap.SetCluster("a", nil)
+	n.SetCluster("a", bootstrap.PublicKey())
+
+	reply, err := n.Join(bootstrap.Listerner.Addr().(*net.TCPAddr).String())
+	if err != nil {
+		panic(err)
+	}
+
+	if reply.Height != 1 || !bytes.Equal(reply.TipID, tip.ID()) {
+		panic(fmt.Errorf("(*Node) Join() should receive chain tip"))
+	}
+
+	if !n.IsInRoutingTable(bootstrap.PublicKey()) || !n.IsInRoutingTable(known.PublicKey) {
+		panic(fmt.Errorf("(*Node) Join() should receive routing table"))
+	}
+
+	if !bootstrap.IsInRoutingTable(n.PublicKey()) {
+		panic(fmt.Errorf("(*Node) AcceptJoin() should add joining node"))
+	}
+
+	if _, rn := bootstrap.GetNodeByPublicKey(n.PublicKey()); rn.Cluster != "a" || rn.Head {
+		panic(fmt.Errorf("(*Node) AcceptJoin() should record cluster of joining node"))
+	}
+
+	if _, rn := n.GetNodeByPublicKey(bootstrap.PublicKey()); rn.Cluster != "a" || !rn.Head {
+		panic(fmt.Errorf("(*Node) Join() should record cluster of bootstrap node"))
+	}
+}
@@ -0,0 +1,393 @@
+package core
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Key file formats.
+const (
+	KeyFormatPEM = "pem" // PKCS#8 in PEM, encrypted with PBES2 (PBKDF2-SHA256, AES-256-CBC) if passphrase is given
+	KeyFormatRaw = "raw" // | public key | private key |, the same as KeyPair in memory, e.g. | key type | x | y | d | of ECDSA
+
+	keyLegacyRawSize    = 96 // ECDSA key pair without key type
+	keyPBKDF2Iterations = 600000
+	keyPBKDF2SaltSize   = 16
+)
+
+// Errors of key files.
+var (
+	ErrKeyPairMismatch   = errors.New("Public key does not match private key")
+	ErrPassphraseNeeded  = errors.New("Key is encrypted, passphrase is needed")
+	ErrInvalidPassphrase = errors.New("Invalid passphrase or corrupted key")
+	ErrUnknownKeyFormat  = errors.New("Unknown key format")
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo ... PKCS#8 EncryptedPrivateKeyInfo.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params ... PBES2-params of RFC 8018.
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params ... PBKDF2-params of RFC 8018.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier
+}
+
+// privateKey ... Decode key pair into private key of crypto packages, it's validated first.
+func (kp *KeyPair) privateKey() (crypto.PrivateKey, error) {
+	err := kp.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kp.Type() {
+	case KeyTypeECDSAP256:
+		_, public, _ := decodeTagged(kp.Public)
+
+		return &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: BytesToBigInt(public[:32]), Y: BytesToBigInt(public[32:])},
+			D:         BytesToBigInt(kp.Private),
+		}, nil
+	case KeyTypeEd25519:
+		return ed25519.NewKeyFromSeed(kp.Private), nil
+	}
+
+	return nil, ErrUnknownKeyType
+}
+
+// keyPairFromPrivateKey ... Encode private key of crypto packages into key pair.
+func keyPairFromPrivateKey(key crypto.PrivateKey) (*KeyPair, error) {
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("Only P-256 key is supported")
+		}
+
+		public, err := encodeECDSAPoint(key.X, key.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := BigIntToBytes(key.D, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		return &KeyPair{Public: encodeTagged(KeyTypeECDSAP256, public), Private: d}, nil
+	case ed25519.PrivateKey:
+		return &KeyPair{Public: encodeTagged(KeyTypeEd25519, key.Public().(ed25519.PublicKey)), Private: key.Seed()}, nil
+	}
+
+	return nil, ErrUnknownKeyType
+}
+
+// importRawKeyPair ... Decode key pair in raw format, i.e. encoded public key followed by private key.
+func importRawKeyPair(data []byte) (*KeyPair, error) {
+	if len(data) == keyLegacyRawSize {
+		// ECDSA key pair without key type.
+		return &KeyPair{Public: append([]byte{}, data[:legacyECDSASize]...), Private: append([]byte{}, data[legacyECDSASize:]...)}, nil
+	}
+
+	if len(data) == 0 {
+		return nil, ErrUnknownKeyFormat
+	}
+
+	s, err := SignatureSchemeOf(KeyType(data[0]))
+	if err != nil || len(data) <= s.PrivateKeySize()+1 {
+		return nil, ErrUnknownKeyFormat
+	}
+
+	split := len(data) - s.PrivateKeySize()
+
+	return &KeyPair{Public: append([]byte{}, data[:split]...), Private: append([]byte{}, data[split:]...)}, nil
+}
+
+// Export ... Encode key pair in given format, private key is encrypted if passphrase isn't empty.
+// Raw format could not be encrypted.
+func (kp *KeyPair) Export(format string, passphrase []byte) ([]byte, error) {
+	key, err := kp.privateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case KeyFormatRaw:
+		if len(passphrase) != 0 {
+			return nil, errors.New("Raw key could not be encrypted")
+		}
+
+		return JoinBytes(kp.Public, kp.Private), nil
+	case KeyFormatPEM:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(passphrase) == 0 {
+			return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+		}
+
+		der, err = encryptPKCS8(der, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), nil
+	}
+
+	return nil, ErrUnknownKeyFormat
+}
+
+// ImportKeyPair ... Decode key pair in PEM or raw format, passphrase is needed for encrypted PEM.
+func ImportKeyPair(data []byte, passphrase []byte) (*KeyPair, error) {
+	var kp *KeyPair
+
+	block, _ := pem.Decode(data)
+
+	if block != nil {
+		der := block.Bytes
+
+		switch block.Type {
+		case "PRIVATE KEY":
+		case "ENCRYPTED PRIVATE KEY":
+			if len(passphrase) == 0 {
+				return nil, ErrPassphraseNeeded
+			}
+
+			var err error
+
+			der, err = decryptPKCS8(der, passphrase)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported PEM block %q", block.Type)
+		}
+
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+
+		kp, err = keyPairFromPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+
+		kp, err = importRawKeyPair(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Key pair should be usable.
+	err := kp.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return kp, nil
+}
+
+// SaveKeyPairFile ... Export key pair into file, it's replaced atomically.
+func SaveKeyPairFile(path string, kp *KeyPair, format string, passphrase []byte) error {
+	data, err := kp.Export(format, passphrase)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// LoadKeyPairFile ... Import key pair from file.
+func LoadKeyPairFile(path string, passphrase []byte) (*KeyPair, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ImportKeyPair(data, passphrase)
+}
+
+// UseKeyPairFile ... Use key pair kept in file, the file is never written to store.
+// If file doesn't exist, it's created with key pair of node, or a new one if node has key of another type.
+func (n *Node) UseKeyPairFile(path, format string, passphrase []byte, t KeyType) error {
+	kp, err := LoadKeyPairFile(path, passphrase)
+	if os.IsNotExist(err) {
+		kp = n.KeyPair()
+		if kp.Type() != t {
+			kp, err = NewKeyPair(t)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = SaveKeyPairFile(path, kp, format, passphrase)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	n.KeyFile = path
+	n.SetKeyPair(kp)
+
+	return nil
+}
+
+// pbes2Key ... Derive AES-256 key from passphrase.
+func pbes2Key(passphrase, salt []byte, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, string(passphrase), salt, iterations, 32)
+}
+
+// encryptPKCS8 ... Encrypt PKCS#8 private key into EncryptedPrivateKeyInfo with PBES2.
+func encryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, keyPBKDF2SaltSize)
+	iv := make([]byte, aes.BlockSize)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	key, err := pbes2Key(passphrase, salt, keyPBKDF2Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#7 padding.
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	data := append(append([]byte{}, der...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: keyPBKDF2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: data,
+	})
+}
+
+// decryptPKCS8 ... Decrypt EncryptedPrivateKeyInfo with PBES2 into PKCS#8 private key.
+func decryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errors.New("Only PBES2 encrypted key is supported")
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) || !params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, errors.New("Only PBKDF2 and AES-256-CBC are supported")
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+
+	if !kdf.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+		return nil, errors.New("Only HMAC-SHA256 is supported by PBKDF2")
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+
+	data := info.EncryptedData
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, ErrInvalidPassphrase
+	}
+
+	key, err := pbes2Key(passphrase, kdf.Salt, kdf.IterationCount)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = append([]byte{}, data...)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(data, data)
+
+	// Wrong passphrase most likely breaks padding.
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(data[len(data)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrInvalidPassphrase
+	}
+
+	return data[:len(data)-padding], nil
+}
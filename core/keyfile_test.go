@@ -0,0 +1,188 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test exporting and importing key pair in every format.
+func TestKeyPairExportImport(t *testing.T) {
+	ecdsaKP, err := NewECDSAKeyPair()
+	if err != nil {
+		panic(err)
+	}
+
+	ed25519KP, err := NewEd25519KeyPair()
+	if err != nil {
+		panic(err)
+	}
+
+	cases := []struct {
+		format     string
+		passphrase []byte
+	}{
+		{KeyFormatPEM, nil},
+		{KeyFormatPEM, []byte("passphrase")},
+		{KeyFormatRaw, nil},
+	}
+
+	for _, kp := range []*KeyPair{ecdsaKP, ed25519KP} {
+		for _, c := range cases {
+			data, err := kp.Export(c.format, c.passphrase)
+			if err != nil {
+				panic(err)
+			}
+
+			imported, err := ImportKeyPair(data, c.passphrase)
+			if err != nil {
+				panic(err)
+			}
+
+			if !bytes.Equal(imported.Public, kp.Public) || !bytes.Equal(imported.Private, kp.Private) {
+				panic(fmt.Errorf("ImportKeyPair() of %s key pair in %s testing failed", kp.Type(), c.format))
+			}
+
+			sig, err := imported.Sign(SHA256([]byte("hash")))
+			if err != nil || !VerifySignature(kp.Public, sig, SHA256([]byte("hash"))) {
+				panic(fmt.Errorf("Imported %s key pair in %s should sign as original one", kp.Type(), c.format))
+			}
+		}
+	}
+
+	kp := ecdsaKP
+
+	if _, err := kp.Export(KeyFormatRaw, []byte("passphrase")); err == nil {
+		panic(fmt.Errorf("(*KeyPair) Export() should not encrypt raw key pair"))
+	}
+
+	if _, err := kp.Export("der", nil); err != ErrUnknownKeyFormat {
+		panic(fmt.Errorf("(*KeyPair) Export() should refuse unknown format"))
+	}
+}
+
+// Test importing encrypted key pair with wrong passphrase.
+func TestImportEncryptedKeyPair(t *testing.T) {
+	kp, err := NewECDSAKeyPair()
+	if err != nil {
+		panic(err)
+	}
+
+	data, err := kp.Export(KeyFormatPEM, []byte("passphrase"))
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := ImportKeyPair(data, nil); err != ErrPassphraseNeeded {
+		panic(fmt.Errorf("ImportKeyPair() should ask for passphrase of encrypted key pair"))
+	}
+
+	// Wrong passphrase breaks either padding or PKCS#8 structure.
+	if _, err := ImportKeyPair(data, []byte("wrong")); err == nil {
+		panic(fmt.Errorf("ImportKeyPair() should refuse wrong passphrase"))
+	}
+}
+
+// Test importing key pair whose public key does not match private key.
+func TestImportMismatchedKeyPair(t *testing.T) {
+	kp1, _ := NewECDSAKeyPair()
+	kp2, _ := NewECDSAKeyPair()
+
+	if _, err := ImportKeyPair(JoinBytes(kp1.Public, kp2.Private), nil); err != ErrKeyPairMismatch {
+		panic(fmt.Errorf("ImportKeyPair() should detect mismatched key pair"))
+	}
+
+	// ECDSA key pair in raw format without key type is still imported.
+	legacy, err := ImportKeyPair(JoinBytes(kp1.Public[1:], kp1.Private), nil)
+	if err != nil || !bytes.Equal(legacy.Public, kp1.Public[1:]) {
+		panic(fmt.Errorf("ImportKeyPair() should import legacy raw key pair"))
+	}
+
+	if _, err := ImportKeyPair([]byte("garbage"), nil); err != ErrUnknownKeyFormat {
+		panic(fmt.Errorf("ImportKeyPair() should refuse unknown format"))
+	}
+}
+
+// Test saving key pair into file and loading it back.
+func TestKeyPairFile(t *testing.T) {
+	kp, _ := NewECDSAKeyPair()
+
+	path := filepath.Join(t.TempDir(), "node.key")
+
+	if _, err := LoadKeyPairFile(path, nil); err == nil {
+		panic(fmt.Errorf("LoadKeyPairFile() should fail on missing file"))
+	}
+
+	if err := SaveKeyPairFile(path, kp, KeyFormatPEM, nil); err != nil {
+		panic(err)
+	}
+
+	loaded, err := LoadKeyPairFile(path, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	if !bytes.Equal(loaded.Public, kp.Public) || !bytes.Equal(loaded.Private, kp.Private) {
+		panic(fmt.Errorf("LoadKeyPairFile() testing failed"))
+	}
+}
+
+// Test encrypted key file is never written in plaintext into store.
+func TestUseKeyPairFileWithStore(t *testing.T) {
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	path := filepath.Join(dir, "node.pem")
+	passphrase := []byte("passphrase")
+
+	kp, _ := NewEd25519KeyPair()
+	if err := SaveKeyPairFile(path, kp, KeyFormatPEM, passphrase); err != nil {
+		panic(err)
+	}
+
+	s, err := NewFileStore(dataDir)
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	n, err := NewNodeWithStore("127.0.0.1", 0, s)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := n.UseKeyPairFile(path, KeyFormatPEM, passphrase, KeyTypeEd25519); err != nil {
+		panic(err)
+	}
+
+	if !bytes.Equal(n.PublicKey(), kp.Public) {
+		panic(fmt.Errorf("(*Node) UseKeyPairFile() should load key pair of file"))
+	}
+
+	// Rotated key is kept out of store too.
+	rotated, _ := NewEd25519KeyPair()
+	n.SetKeyPair(rotated)
+
+	if stored, err := s.LoadKeyPair(); err != nil || stored != nil {
+		panic(fmt.Errorf("Key pair of key file should not be saved into store"))
+	}
+
+	files, _ := os.ReadDir(dataDir)
+	for _, f := range files {
+		data, _ := os.ReadFile(filepath.Join(dataDir, f.Name()))
+
+		for _, private := range [][]byte{kp.Private, rotated.Private} {
+			encoded := [][]byte{private, []byte(Base58Encode(private)), []byte(base64.StdEncoding.EncodeToString(private))}
+
+			if bytes.Contains(data, encoded[0]) || bytes.Contains(data, encoded[1]) || bytes.Contains(data, encoded[2]) {
+				panic(fmt.Errorf("Plaintext private key should not be in data directory, found in %s", f.Name()))
+			}
+		}
+	}
+
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		panic(fmt.Errorf("Key file should be readable by owner only"))
+	}
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Errors of light node.
+var (
+	ErrNotLightNode     = errors.New("Node is not light node")
+	ErrUnverifiedProof  = errors.New("Transaction could not be verified against block headers")
+	ErrTransactionProof = errors.New("No node proves transaction")
+)
+
+// HeaderChain ... Chain of signed block headers, light node keeps it instead of blocks.
+type HeaderChain struct {
+	Headers []SignedHeader // Headers from the first block on
+}
+
+// Height ... Get number of headers in chain.
+func (hc HeaderChain) Height() int {
+	return len(hc.Headers)
+}
+
+// LastHeader ... Get the last header (tip) of chain.
+func (hc HeaderChain) LastHeader() (bool, SignedHeader) {
+	if len(hc.Headers) == 0 {
+		return false, SignedHeader{}
+	}
+
+	return true, hc.Headers[len(hc.Headers)-1]
+}
+
+// GetHeaderByID ... Get header of block with given id.
+func (hc HeaderChain) GetHeaderByID(id []byte) (bool, SignedHeader) {
+	for i := len(hc.Headers) - 1; i >= 0; i-- {
+		if bytes.Equal(hc.Headers[i].ID(), id) {
+			return true, hc.Headers[i]
+		}
+	}
+
+	return false, SignedHeader{}
+}
+
+// ValidateHeader ... Validate header on top of chain, as Blockchain.Validate() does for block without transactions.
+func (hc HeaderChain) ValidateHeader(h SignedHeader) error {
+	headerErr := func(reason string) error {
+		return &ValidationError{BlockIndex: hc.Height(), BlockID: h.ID(), Reason: reason}
+	}
+
+	if found, prev := hc.LastHeader(); !found {
+		if len(h.Header.PrevBlockID) != 0 {
+			return headerErr("first block should not have previous block")
+		}
+	} else {
+		if !bytes.Equal(h.Header.PrevBlockID, prev.ID()) {
+			return headerErr("previous block id mismatch")
+		}
+
+		if h.Header.Timestamp < prev.Header.Timestamp-BlockTimestampTolerance {
+			return headerErr("timestamp is earlier than previous block")
+		}
+	}
+
+	if !h.VerifySignature() {
+		return headerErr("invalid generator signature")
+	}
+
+	return nil
+}
+
+// GetSignedHeaders ... Get headers of chain, light node keeps them only.
+func (n *Node) GetSignedHeaders() (int, []SignedHeader) {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	if n.Light {
+		headers := append([]SignedHeader{}, n.Headers.Headers...)
+		return len(headers), headers
+	}
+
+	var headers []SignedHeader
+	for _, b := range n.Chain.Blocks {
+		headers = append(headers, b.SignedHeader())
+	}
+
+	return len(headers), headers
+}
+
+// GetProof ... Get transaction in chain with its merkle proof, for light nodes.
+func (n *Node) GetProof(id []byte) ProofData {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	found, h, proof := n.merkleProofOf(id)
+	if !found {
+		return ProofData{}
+	}
+
+	_, loc := n.Index.LocateTransaction(id)
+
+	return ProofData{Found: true, Header: h, Proof: proof, Transaction: n.transactionAt(loc)}
+}
+
+// SyncHeaders ... Catch up with headers of chain of node at given address, and return number of headers added.
+// Light node follows the longest chain of headers, and every header is validated before it's added.
+func (n *Node) SyncHeaders(address string) (int, error) {
+	if !n.Light {
+		return 0, ErrNotLightNode
+	}
+
+	n.ChainLock.RLock()
+	from := n.Headers.Height()
+	n.ChainLock.RUnlock()
+
+	added := 0
+	back := 1
+
+	for {
+		headers, err := n.requestHeaders(address, from, MaxHeadersPerRequest)
+		if err != nil {
+			return added, err
+		}
+
+		if len(headers) == 0 {
+			return added, nil
+		}
+
+		b, err := n.extendHeaders(from, headers)
+		if err == errHeadersDetached {
+			if from == 0 {
+				return added, ErrInvalidSyncReply
+			}
+
+			// Peer's chain forks below, go back further.
+			from = maxInt(from-back, 0)
+			back *= 2
+			continue
+		}
+
+		added += b
+
+		if err != nil {
+			return added, err
+		}
+
+		if len(headers) < MaxHeadersPerRequest {
+			return added, nil
+		}
+
+		from += len(headers)
+	}
+}
+
+// errHeadersDetached ... Headers don't extend header chain at given height.
+var errHeadersDetached = errors.New("Headers are detached from chain")
+
+// extendHeaders ... Replace headers from given height on, if the result is longer, and return number of new headers.
+func (n *Node) extendHeaders(from int, headers []SignedHeader) (int, error) {
+	n.ChainLock.Lock()
+	defer n.ChainLock.Unlock()
+
+	if from > n.Headers.Height() {
+		return 0, errHeadersDetached
+	}
+
+	chain := HeaderChain{Headers: append([]SignedHeader{}, n.Headers.Headers[:from]...)}
+
+	if found, last := chain.LastHeader(); found && !bytes.Equal(headers[0].Header.PrevBlockID, last.ID()) {
+		return 0, errHeadersDetached
+	}
+
+	if from+len(headers) <= n.Headers.Height() {
+		// Not longer than our chain, at least not yet.
+		return 0, nil
+	}
+
+	added := 0
+	fork := -1 // Height of the first header which differs from ours
+
+	for i, h := range headers {
+		err := chain.ValidateHeader(h)
+		if err != nil {
+			return 0, err
+		}
+
+		if from+i >= n.Headers.Height() || !bytes.Equal(n.Headers.Headers[from+i].ID(), h.ID()) {
+			added++
+
+			if fork < 0 {
+				fork = from + i
+			}
+		}
+
+		chain.Headers = append(chain.Headers, h)
+	}
+
+	if fork < 0 {
+		return 0, nil
+	}
+
+	err := n.saveHeaders(fork, chain)
+
+	return added, err
+}
+
+// saveHeaders ... Replace headers from given height on by headers of given chain, they're written to store first,
+// so header chain in memory never goes ahead of store.
+// NOTE: Caller should hold chain lock.
+func (n *Node) saveHeaders(fork int, chain HeaderChain) error {
+	if n.Store == nil {
+		n.Headers = chain
+		return nil
+	}
+
+	err := n.Store.TruncateHeaders(fork)
+	if err != nil {
+		return err
+	}
+
+	n.Headers.Headers = n.Headers.Headers[:fork]
+
+	for _, h := range chain.Headers[fork:] {
+		err = n.Store.AppendHeader(h)
+		if err != nil {
+			return err
+		}
+
+		n.Headers.Headers = append(n.Headers.Headers, h)
+	}
+
+	return nil
+}
+
+// RequestProof ... Request transaction from node at given address, and verify it against block headers.
+func (n *Node) RequestProof(address string, id []byte) (ProofData, error) {
+	m := n.SignMessage(NewGetProofMessage(id))
+
+	mjson, err := m.MarshalJson()
+	if err != nil {
+		return ProofData{}, err
+	}
+
+	var reply ProofData
+
+	err = n.Send(address, mjson, func(data []byte) error {
+		return reply.UnmarshalJson(data)
+	})
+
+	if err != nil {
+		return ProofData{}, err
+	}
+
+	if !reply.Found || !bytes.Equal(reply.Transaction.ID(), id) {
+		return ProofData{}, ErrUnverifiedProof
+	}
+
+	n.ChainLock.RLock()
+	found, _ := n.Headers.GetHeaderByID(reply.Header.ID())
+	n.ChainLock.RUnlock()
+
+	if !found || !reply.Header.VerifyMerkleProof(reply.Transaction, reply.Proof) {
+		return ProofData{}, ErrUnverifiedProof
+	}
+
+	return reply, nil
+}
+
+// RequestProofFromPeers ... Request transaction from cluster head first, then other heads, which keep ledger.
+func (n *Node) RequestProofFromPeers(id []byte) (ProofData, error) {
+	var nodes []RemoteNode
+
+	if b, head := n.GetClusterHeadNode(); b {
+		nodes = append(nodes, head)
+	}
+
+	_, heads := n.GetClusterHeads()
+	nodes = append(nodes, heads...)
+
+	for _, rn := range nodes {
+		if pd, err := n.RequestProof(rn.Address, id); err == nil {
+			return pd, nil
+		}
+	}
+
+	return ProofData{}, ErrTransactionProof
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Test light node syncing headers and verifying transactions.
+func TestLightNode(t *testing.T) {
+	src, bc := GenValidChain(3, 2)
+	addr := RunSyncNode(src)
+	defer src.Listerner.Close()
+
+	n, _ := NewNode("127.0.0.1", 0)
+	n.Light = true
+	defer n.Peers.Close()
+
+	if n.IsClusterHead() {
+		panic(fmt.Errorf("(*Node) IsClusterHead() should be false for light node"))
+	}
+
+	added, err := n.SyncChain(addr)
+	if err != nil {
+		panic(err)
+	}
+
+	_, tip := bc.LastBlock()
+
+	if added != 3 || !n.HasBlock(tip.ID()) || n.Chain.Height() != 0 || n.Tree.Size() != 0 {
+		panic(fmt.Errorf("(*Node) SyncHeaders() testing failed, %d headers added", added))
+	}
+
+	// Synced already.
+	if added, err := n.SyncHeaders(addr); added != 0 || err != nil {
+		panic(fmt.Errorf("(*Node) SyncHeaders() should add nothing when synced: %v", err))
+	}
+
+	tr := bc.Blocks[1].Transactions[0]
+
+	pd, err := n.RequestProof(addr, tr.ID())
+	if err != nil {
+		panic(err)
+	}
+
+	if !pd.Transaction.EqualWith(tr) || !bytes.Equal(pd.Header.ID(), bc.Blocks[1].ID()) {
+		panic(fmt.Errorf("(*Node) RequestProof() testing failed"))
+	}
+
+	if _, err := n.RequestProof(addr, GenRandomBytes(32)); err != ErrUnverifiedProof {
+		panic(fmt.Errorf("(*Node) RequestProof() should fail for unknown transaction, got %v", err))
+	}
+
+	// Header with forged signature is rejected.
+	var hc HeaderChain
+	h := bc.Blocks[0].SignedHeader()
+	h.Signature = bc.Blocks[1].Signature
+
+	if err := hc.ValidateHeader(h); err == nil {
+		panic(fmt.Errorf("(HeaderChain) ValidateHeader() accepted forged signature"))
+	}
+
+	if _, err := src.SyncHeaders(addr); err != ErrNotLightNode {
+		panic(fmt.Errorf("(*Node) SyncHeaders() should be only for light node"))
+	}
+}
+
+// Test light node restoring headers from store.
+func TestLightNodeWithStore(t *testing.T) {
+	src, bc := GenValidChain(3, 2)
+	addr := RunSyncNode(src)
+	defer src.Listerner.Close()
+
+	dir := t.TempDir()
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	n, _ := NewNodeWithStore("127.0.0.1", 0, s)
+	n.Light = true
+	defer n.Peers.Close()
+
+	if added, err := n.SyncHeaders(addr); added != 3 || err != nil {
+		panic(fmt.Errorf("(*Node) SyncHeaders() testing failed: %v", err))
+	}
+
+	// Longer chain replaces headers in store too.
+	_, other := GenValidChain(4, 1)
+
+	var headers []SignedHeader
+	for _, b := range other.Blocks {
+		headers = append(headers, b.SignedHeader())
+	}
+
+	if added, err := n.extendHeaders(0, headers); added != 4 || err != nil {
+		panic(fmt.Errorf("(*Node) extendHeaders() should replace shorter chain: %v", err))
+	}
+
+	s.Close()
+
+	// Restart node.
+	s, err = NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	r, err := NewNodeWithStore("127.0.0.1", 0, s)
+	if err != nil {
+		panic(err)
+	}
+
+	r.Light = true
+
+	_, tip := other.LastBlock()
+	_, old := bc.LastBlock()
+
+	if r.Headers.Height() != 4 || !r.HasBlock(tip.ID()) || r.HasBlock(old.ID()) {
+		panic(fmt.Errorf("NewNodeWithStore() should restore headers of light node"))
+	}
+}
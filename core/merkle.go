@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Prefixes of merkle tree nodes, so that a leaf can never be taken as an inner node.
+const (
+	merkleLeafPrefix  byte = 0x00
+	merkleInnerPrefix byte = 0x01
+)
+
+// MerkleProofNode ... Sibling hash on the path from leaf to root.
+type MerkleProofNode struct {
+	Hash []byte `json:"hash"` // Hash of sibling
+	Left bool   `json:"left"` // Sibling is on the left side
+}
+
+// MerkleProof ... Inclusion proof of transaction in block.
+type MerkleProof struct {
+	TransactionID []byte            `json:"transaction_id"` // Transaction id
+	Path          []MerkleProofNode `json:"path"`           // Siblings from leaf to root
+}
+
+// merkleLeaf ... Hash of merkle tree leaf.
+func merkleLeaf(data []byte) []byte {
+	return SHA256(JoinBytes([]byte{merkleLeafPrefix}, data))
+}
+
+// merkleParent ... Hash of merkle tree inner node.
+func merkleParent(left, right []byte) []byte {
+	return SHA256(JoinBytes([]byte{merkleInnerPrefix}, left, right))
+}
+
+// merkleLevels ... Build merkle tree from leaves, levels[0] are leaves and the last level is root.
+// If one level has odd number of nodes, the last node is promoted to upper level as is.
+func merkleLevels(leaves [][]byte) [][][]byte {
+	levels := [][][]byte{leaves}
+
+	for level := leaves; len(level) > 1; {
+		var upper [][]byte
+
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				upper = append(upper, merkleParent(level[i], level[i+1]))
+			} else {
+				upper = append(upper, level[i])
+			}
+		}
+
+		levels = append(levels, upper)
+		level = upper
+	}
+
+	return levels
+}
+
+// merkleLeaves ... Get leaves of transactions.
+// Leaves are hashes of canonical transactions instead of bare transaction ids,
+// because transaction id only covers public keys and timestamp.
+func (ts TransactionSlice) merkleLeaves() [][]byte {
+	var leaves [][]byte
+	for _, t := range ts {
+		leaves = append(leaves, merkleLeaf(t.Serialize()))
+	}
+
+	return leaves
+}
+
+// MerkleRoot ... Get merkle root of transactions.
+func (ts TransactionSlice) MerkleRoot() []byte {
+	if len(ts) == 0 {
+		return SHA256(nil)
+	}
+
+	levels := merkleLevels(ts.merkleLeaves())
+
+	return levels[len(levels)-1][0]
+}
+
+// MerkleProof ... Get inclusion proof of transaction with given id.
+func (ts TransactionSlice) MerkleProof(id []byte) (bool, MerkleProof) {
+	b, i := ts.ContainsByID(id)
+	if !b {
+		return false, MerkleProof{}
+	}
+
+	proof := MerkleProof{TransactionID: id}
+
+	levels := merkleLevels(ts.merkleLeaves())
+
+	for _, level := range levels[:len(levels)-1] {
+		sibling := i ^ 1
+
+		if sibling < len(level) {
+			proof.Path = append(proof.Path, MerkleProofNode{
+				Hash: level[sibling],
+				Left: sibling < i,
+			})
+		}
+
+		i /= 2
+	}
+
+	return true, proof
+}
+
+// VerifyMerkleProof ... Verify that transaction is included by given merkle root.
+func VerifyMerkleProof(root []byte, t Transaction, proof MerkleProof) bool {
+	if !bytes.Equal(t.ID(), proof.TransactionID) {
+		return false
+	}
+
+	h := merkleLeaf(t.Serialize())
+
+	for _, node := range proof.Path {
+		if node.Left {
+			h = merkleParent(node.Hash, h)
+		} else {
+			h = merkleParent(h, node.Hash)
+		}
+	}
+
+	return bytes.Equal(h, root)
+}
+
+// MarshalJson ... Serialize MerkleProof into Json.
+func (p MerkleProof) MarshalJson() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// UnmarshalJson ... Read MerkleProof from Json.
+func (p *MerkleProof) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &p)
+}
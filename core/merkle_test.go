@@ -0,0 +1,61 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Test merkle inclusion proofs for every transaction, with odd and even number of leaves.
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		trs := GenRandomTransactionSlice(n)
+		root := trs.MerkleRoot()
+
+		for _, tr := range trs {
+			b, proof := trs.MerkleProof(tr.ID())
+			if !b {
+				panic(fmt.Errorf("(TransactionSlice) MerkleProof() testing failed"))
+			}
+
+			if !VerifyMerkleProof(root, tr, proof) {
+				panic(fmt.Errorf("VerifyMerkleProof() testing failed"))
+			}
+
+			// Tampered transaction should not be verified.
+			fake := tr
+			fake.Output.Accepted++
+
+			if VerifyMerkleProof(root, fake, proof) {
+				panic(fmt.Errorf("VerifyMerkleProof() verified tampered transaction"))
+			}
+		}
+	}
+
+	if b, _ := GenRandomTransactionSlice(3).MerkleProof(GenRandomBytes(32)); b {
+		panic(fmt.Errorf("(TransactionSlice) MerkleProof() found unknown transaction"))
+	}
+}
+
+// Test MerkleProof marshal function.
+func TestMerkleProofMarshalJson(t *testing.T) {
+	trs := GenRandomTransactionSlice(5)
+
+	_, p1 := trs.MerkleProof(trs[2].ID())
+
+	p1json, err := p1.MarshalJson()
+	if err != nil {
+		panic(fmt.Errorf("(MerkleProof) MarshalJson() testing failed"))
+	}
+
+	var p2 MerkleProof
+
+	err = p2.UnmarshalJson(p1json)
+	if err != nil {
+		panic(fmt.Errorf("(*MerkleProof) UnmarshalJson() testing failed"))
+	}
+
+	if !VerifyMerkleProof(trs.MerkleRoot(), trs[2], p2) || !bytes.Equal(p1.TransactionID, p2.TransactionID) {
+		panic(fmt.Errorf("(MerkleProof) MarshalJson()/UnmarshalJson() testing failed"))
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 )
 
 const (
@@ -16,12 +17,19 @@ const (
 	PendingTransaction   byte = 0x05 // Send pending transaction
 	BroadcastTransaction byte = 0x06 // Broadcast transaction by requestee node
 	SyncTransactions     byte = 0x07 // Sync transactions
+	AnnounceTip          byte = 0x08 // Announce tip of chain
+	GetHeaders           byte = 0x09 // Request block headers of chain by height range
+	GetBlocks            byte = 0x0a // Request blocks by id
+	GetProof             byte = 0x0b // Request transaction with its merkle proof
+	KeyRotation          byte = 0x0c // Announce key rotation of sender
 )
 
 // PingData ... Ping data.
 type PingData struct {
 	PublicKey []byte `json:"public_key"`
 	Address   string `json:"server_addr"`
+	Cluster   string `json:"cluster,omitempty"` // Cluster of sender
+	Head      bool   `json:"head,omitempty"`    // Whether sender is head of its cluster
 }
 
 // MarshalJson ... Serialize PingData into Json.
@@ -36,12 +44,80 @@ func (p *PingData) UnmarshalJson(data []byte) error {
 
 // NewPingMessage ... Generate new ping message.
 func NewPingMessage(pk []byte, addr string) Message {
-	data := PingData{pk, addr}
+	data := PingData{PublicKey: pk, Address: addr}
 	dataJSON, _ := data.MarshalJson()
 
 	return Message{Type: Ping, Data: dataJSON}
 }
 
+// JoinData ... Join network through bootstrap node.
+type JoinData struct {
+	PublicKey []byte `json:"public_key"`
+	Address   string `json:"server_addr"`
+	Cluster   string `json:"cluster,omitempty"` // Cluster of joining node
+	Head      bool   `json:"head,omitempty"`    // Whether joining node is head of its cluster
+}
+
+// MarshalJson ... Serialize JoinData into Json.
+func (j JoinData) MarshalJson() ([]byte, error) {
+	return json.Marshal(j)
+}
+
+// UnmarshalJson ... Read JoinData from Json.
+func (j *JoinData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &j)
+}
+
+// NewJoinMessage ... Generate new join message.
+func NewJoinMessage(pk []byte, addr string) Message {
+	data := JoinData{PublicKey: pk, Address: addr}
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: Join, Data: dataJSON}
+}
+
+// JoinReplyData ... Reply of bootstrap node to join message.
+type JoinReplyData struct {
+	PublicKey []byte       `json:"public_key"`        // Public key of bootstrap node
+	Address   string       `json:"server_addr"`       // Address of bootstrap node
+	Nodes     []RemoteNode `json:"nodes"`             // Routing table of bootstrap node
+	Height    int          `json:"height"`            // Number of blocks in chain of bootstrap node
+	TipID     []byte       `json:"tip_id"`            // ID of last block, empty if chain is empty
+	Cluster   string       `json:"cluster,omitempty"` // Cluster of bootstrap node
+	Head      bool         `json:"head,omitempty"`    // Whether bootstrap node is head of its cluster
+}
+
+// EqualWith ... Test if two JoinReplyData are equal.
+func (jr JoinReplyData) EqualWith(temp JoinReplyData) bool {
+	if !bytes.Equal(jr.PublicKey, temp.PublicKey) || jr.Address != temp.Address {
+		return false
+	}
+
+	if !(SyncNodesData{jr.Nodes}).EqualWith(SyncNodesData{temp.Nodes}) {
+		return false
+	}
+
+	if jr.Height != temp.Height || !bytes.Equal(jr.TipID, temp.TipID) {
+		return false
+	}
+
+	if jr.Cluster != temp.Cluster || jr.Head != temp.Head {
+		return false
+	}
+
+	return true
+}
+
+// MarshalJson ... Serialize JoinReplyData into Json.
+func (jr JoinReplyData) MarshalJson() ([]byte, error) {
+	return json.Marshal(jr)
+}
+
+// UnmarshalJson ... Read JoinReplyData from Json.
+func (jr *JoinReplyData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &jr)
+}
+
 // SyncNodesData ... Sync nodes.
 type SyncNodesData struct {
 	Nodes []RemoteNode `json:"nodes"`
@@ -185,10 +261,186 @@ func NewSyncTransactionsMessage(transactions TransactionSlice) Message {
 	return Message{Type: SyncTransactions, Data: dataJSON}
 }
 
+// KeyRotationData ... Key rotation.
+type KeyRotationData struct {
+	Transaction `json:"transaction"`
+}
+
+// MarshalJson ... Serialize KeyRotationData into Json.
+func (kr KeyRotationData) MarshalJson() ([]byte, error) {
+	return json.Marshal(kr)
+}
+
+// UnmarshalJson ... Read KeyRotationData from Json.
+func (kr *KeyRotationData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &kr)
+}
+
+// NewKeyRotationMessage ... Generate new key rotation message.
+func NewKeyRotationMessage(t Transaction) Message {
+	data := KeyRotationData{t}
+
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: KeyRotation, Data: dataJSON}
+}
+
+// TipData ... Announce tip of chain.
+type TipData struct {
+	PublicKey []byte `json:"public_key"`  // Public key of announcing node
+	Address   string `json:"server_addr"` // Address of announcing node, to sync from
+	Height    int    `json:"height"`      // Number of blocks in chain
+	TipID     []byte `json:"tip_id"`      // ID of last block
+}
+
+// MarshalJson ... Serialize TipData into Json.
+func (td TipData) MarshalJson() ([]byte, error) {
+	return json.Marshal(td)
+}
+
+// UnmarshalJson ... Read TipData from Json.
+func (td *TipData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &td)
+}
+
+// NewAnnounceTipMessage ... Generate new announce tip message.
+func NewAnnounceTipMessage(pk []byte, addr string, height int, tipID []byte) Message {
+	data := TipData{pk, addr, height, tipID}
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: AnnounceTip, Data: dataJSON}
+}
+
+// GetHeadersData ... Request block headers of chain, from given height on.
+type GetHeadersData struct {
+	From  int `json:"from"`  // Height of the first header
+	Count int `json:"count"` // Maximum number of headers
+}
+
+// MarshalJson ... Serialize GetHeadersData into Json.
+func (gh GetHeadersData) MarshalJson() ([]byte, error) {
+	return json.Marshal(gh)
+}
+
+// UnmarshalJson ... Read GetHeadersData from Json.
+func (gh *GetHeadersData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &gh)
+}
+
+// NewGetHeadersMessage ... Generate new get headers message.
+func NewGetHeadersMessage(from, count int) Message {
+	data := GetHeadersData{from, count}
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: GetHeaders, Data: dataJSON}
+}
+
+// HeadersData ... Reply to get headers message.
+type HeadersData struct {
+	Headers []SignedHeader `json:"headers"`
+}
+
+// MarshalJson ... Serialize HeadersData into Json.
+func (hd HeadersData) MarshalJson() ([]byte, error) {
+	return json.Marshal(hd)
+}
+
+// UnmarshalJson ... Read HeadersData from Json.
+func (hd *HeadersData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &hd)
+}
+
+// GetBlocksData ... Request blocks by id.
+type GetBlocksData struct {
+	IDs [][]byte `json:"ids"`
+}
+
+// MarshalJson ... Serialize GetBlocksData into Json.
+func (gb GetBlocksData) MarshalJson() ([]byte, error) {
+	return json.Marshal(gb)
+}
+
+// UnmarshalJson ... Read GetBlocksData from Json.
+func (gb *GetBlocksData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &gb)
+}
+
+// NewGetBlocksMessage ... Generate new get blocks message.
+func NewGetBlocksMessage(ids [][]byte) Message {
+	data := GetBlocksData{ids}
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: GetBlocks, Data: dataJSON}
+}
+
+// BlocksData ... Reply to get blocks message.
+type BlocksData struct {
+	Blocks BlockSlice `json:"blocks"`
+}
+
+// MarshalJson ... Serialize BlocksData into Json.
+func (bd BlocksData) MarshalJson() ([]byte, error) {
+	return json.Marshal(bd)
+}
+
+// UnmarshalJson ... Read BlocksData from Json.
+func (bd *BlocksData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &bd)
+}
+
+// GetProofData ... Request transaction in chain, with its merkle proof.
+type GetProofData struct {
+	TransactionID []byte `json:"transaction_id"`
+}
+
+// MarshalJson ... Serialize GetProofData into Json.
+func (gp GetProofData) MarshalJson() ([]byte, error) {
+	return json.Marshal(gp)
+}
+
+// UnmarshalJson ... Read GetProofData from Json.
+func (gp *GetProofData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &gp)
+}
+
+// NewGetProofMessage ... Generate new get proof message.
+func NewGetProofMessage(id []byte) Message {
+	data := GetProofData{id}
+	dataJSON, _ := data.MarshalJson()
+
+	return Message{Type: GetProof, Data: dataJSON}
+}
+
+// ProofData ... Reply to get proof message.
+type ProofData struct {
+	Found       bool        `json:"found"`       // Whether transaction is in chain
+	Header      BlockHeader `json:"header"`      // Header of block including transaction
+	Proof       MerkleProof `json:"proof"`       // Merkle proof of transaction
+	Transaction Transaction `json:"transaction"` // Transaction
+}
+
+// MarshalJson ... Serialize ProofData into Json.
+func (pd ProofData) MarshalJson() ([]byte, error) {
+	return json.Marshal(pd)
+}
+
+// UnmarshalJson ... Read ProofData from Json.
+func (pd *ProofData) UnmarshalJson(data []byte) error {
+	return json.Unmarshal(data, &pd)
+}
+
+// MessageNonceSize ... Size of nonce of signed message.
+const MessageNonceSize = 16
+
 // Message ... Message carrier.
+// The envelope (sender, nonce, timestamp and signature) is optional, it's empty for unsigned message.
 type Message struct {
-	Type byte   `json:"type"` // Message type
-	Data []byte `json:"data"` // Raw data
+	Type      byte   `json:"type"`                // Message type
+	Data      []byte `json:"data"`                // Raw data
+	Sender    []byte `json:"sender,omitempty"`    // Public key of sender
+	Nonce     []byte `json:"nonce,omitempty"`     // Random nonce, against replaying
+	Timestamp int    `json:"timestamp,omitempty"` // Unix timestamp of signing
+	Signature []byte `json:"signature,omitempty"` // Signature by sender
 }
 
 // EqualWith ... Test if two messages are equal.
@@ -201,9 +453,58 @@ func (m Message) EqualWith(temp Message) bool {
 		return false
 	}
 
+	if !bytes.Equal(m.Sender, temp.Sender) || !bytes.Equal(m.Nonce, temp.Nonce) {
+		return false
+	}
+
+	if m.Timestamp != temp.Timestamp || !bytes.Equal(m.Signature, temp.Signature) {
+		return false
+	}
+
 	return true
 }
 
+// Hash ... Get SHA256 sum of message, which is signed by sender.
+func (m Message) Hash() []byte {
+	return SHA256(JoinBytesWithLength(
+		[]byte{m.Type},
+		m.Data,
+		m.Sender,
+		m.Nonce,
+		UInt64ToBytes(uint64(m.Timestamp))))
+}
+
+// Sign ... Sign message with key pair of sender.
+func (m *Message) Sign(kp *KeyPair) error {
+	nonce, err := GenSecureRandomBytes(MessageNonceSize)
+	if err != nil {
+		return err
+	}
+
+	m.Sender = kp.Public
+	m.Nonce = nonce
+	m.Timestamp = int(time.Now().Unix())
+
+	sig, err := kp.Sign(m.Hash())
+	if err != nil {
+		return err
+	}
+
+	m.Signature = sig
+
+	return nil
+}
+
+// IsSigned ... Test if message carries signature.
+func (m Message) IsSigned() bool {
+	return len(m.Signature) != 0
+}
+
+// VerifySignature ... Verify signature of sender.
+func (m Message) VerifySignature() bool {
+	return VerifySignature(m.Sender, m.Signature, m.Hash())
+}
+
 // MarshalJson ... Serialize message into Json.
 func (m Message) MarshalJson() ([]byte, error) {
 	return json.Marshal(m)
@@ -35,6 +35,33 @@ func TestPingDataMarshalJson(t *testing.T) {
 	}
 }
 
+// Test JoinReplyData marshal function.
+func TestJoinReplyDataMarshalJson(t *testing.T) {
+	jr1 := JoinReplyData{
+		PublicKey: GenRandomBytes(64),
+		Address:   "127.0.0.1:3000",
+		Nodes:     GenRandomRemoteNodes(3),
+		Height:    10,
+		TipID:     GenRandomBytes(32),
+	}
+
+	jr1json, err := jr1.MarshalJson()
+	if err != nil {
+		panic(fmt.Errorf("(JoinReplyData) MarshalJson() testing failed"))
+	}
+
+	var jr2 JoinReplyData
+
+	err = jr2.UnmarshalJson(jr1json)
+	if err != nil {
+		panic(fmt.Errorf("(*JoinReplyData) UnmarshalJson() testing failed"))
+	}
+
+	if !jr1.EqualWith(jr2) {
+		panic(fmt.Errorf("(JoinReplyData) MarshalJson()/UnmarshalJson() testing failed"))
+	}
+}
+
 // Test SyncNodesData marshal function.
 func TestSyncNodesDataMarshalJson(t *testing.T) {
 	sn1 := SyncNodesData{Nodes: GenRandomRemoteNodes(5)}
@@ -145,3 +172,34 @@ func TestMessageMarshalJson(t *testing.T) {
 		panic(fmt.Errorf("(Message) MarshalJson()/UnmarshalJson() testing failed"))
 	}
 }
+
+// Test signed message envelope.
+func TestMessageSignature(t *testing.T) {
+	kp, _ := NewECDSAKeyPair()
+
+	m1 := GenRandomMessage()
+
+	if m1.IsSigned() || m1.VerifySignature() {
+		panic(fmt.Errorf("(Message) IsSigned() testing failed"))
+	}
+
+	if err := m1.Sign(kp); err != nil {
+		panic(err)
+	}
+
+	m1json, _ := m1.MarshalJson()
+
+	var m2 Message
+
+	_ = m2.UnmarshalJson(m1json)
+
+	if !m2.IsSigned() || !m2.VerifySignature() || !m1.EqualWith(m2) {
+		panic(fmt.Errorf("(Message) VerifySignature() testing failed"))
+	}
+
+	m2.Type++
+
+	if m2.VerifySignature() {
+		panic(fmt.Errorf("(Message) VerifySignature() accepted retyped message"))
+	}
+}
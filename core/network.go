@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net"
 	"sort"
 	"strconv"
@@ -10,9 +11,8 @@ import (
 	"time"
 )
 
-const (
-	MAX_RECV_PACKET = 4096 // Max receiving packet length
-)
+// RoutingTableSavePeriod ... Routing table which only has lastseen of nodes changed is saved at most once in this period.
+const RoutingTableSavePeriod = time.Minute
 
 // RemoteNode ... Represent other nodes.
 type RemoteNode struct {
@@ -20,6 +20,8 @@ type RemoteNode struct {
 	Address    string        // Address
 	Lastseen   int           // The unix time of seeing this node last time
 	VerifiedBy []*RemoteNode // Nodes that verify this node
+	Cluster    string        // Cluster that node declares, empty if node does not join any cluster
+	Head       bool          // Whether node declares itself as head of its cluster
 }
 
 // RemoteNodeJSONImpl ...
@@ -27,6 +29,8 @@ type RemoteNodeJSONImpl struct {
 	PublicKey string `json:"public_key"`
 	Address   string `json:"address"`
 	Lastseen  int    `json:"lastseen"`
+	Cluster   string `json:"cluster,omitempty"`
+	Head      bool   `json:"head,omitempty"`
 }
 
 // Addr ... Get address of remote node.
@@ -45,6 +49,8 @@ func (rn RemoteNode) MarshalJSON() ([]byte, error) {
 		PublicKey: Base58Encode(rn.PublicKey),
 		Address:   rn.Address,
 		Lastseen:  rn.Lastseen,
+		Cluster:   rn.Cluster,
+		Head:      rn.Head,
 	})
 }
 
@@ -60,6 +66,8 @@ func (rn *RemoteNode) UnmarshalJSON(data []byte) error {
 	rn.PublicKey = Base58Decode(r.PublicKey)
 	rn.Address = r.Address
 	rn.Lastseen = r.Lastseen
+	rn.Cluster = r.Cluster
+	rn.Head = r.Head
 
 	return nil
 }
@@ -78,24 +86,59 @@ func (rn RemoteNode) EqualWith(temp RemoteNode) bool {
 		return false
 	}
 
+	if rn.Cluster != temp.Cluster || rn.Head != temp.Head {
+		return false
+	}
+
 	return true
 }
 
 // Packet ... Received packect.
 type Packet struct {
-	Content []byte       // Raw bytes
-	Conn    *net.TCPConn // TCP connection
+	Content   []byte    // Raw bytes
+	Conn      *PeerConn // Peer connection
+	RequestID uint64    // Request id on connection
 }
 
 // IncommingMessage ...
 type IncommingMessage struct {
-	Content Message      // Message
-	Conn    *net.TCPConn // TCP connection
+	Content   Message    // Message
+	Conn      *PeerConn  // Peer connection
+	RequestID uint64     // Request id on connection
+	replied   *sync.Once // Every request gets exactly one response
+}
+
+// newIncommingMessage ... Generate new incomming message of packet.
+func newIncommingMessage(m Message, p Packet) IncommingMessage {
+	return IncommingMessage{Content: m, Conn: p.Conn, RequestID: p.RequestID, replied: new(sync.Once)}
+}
+
+// Reply ... Reply to sender of message, only the first reply is sent.
+func (m IncommingMessage) Reply(data []byte) error {
+	var err error
+
+	m.replied.Do(func() {
+		err = m.Conn.Respond(m.RequestID, data)
+	})
+
+	return err
+}
+
+// SenderPK ... Get public key of sender, which is proved in handshake of connection.
+func (m IncommingMessage) SenderPK() []byte {
+	return m.Conn.RemotePublicKey()
+}
+
+// Close ... Finish processing message, sender gets an empty reply if handler didn't reply.
+// The connection itself is kept for following messages.
+func (m IncommingMessage) Close() error {
+	return m.Reply(nil)
 }
 
 // Node ... Represent ourselves.
 type Node struct {
-	Keypair                 *KeyPair                // Key pair
+	keypairLock             sync.RWMutex            // Key pair read write lock
+	keypair                 *KeyPair                // Key pair, read through KeyPair()
 	IP                      string                  // IP address
 	Port                    int                     // Port
 	RoutingTableLock        sync.RWMutex            // Routing table read write lock
@@ -106,9 +149,28 @@ type Node struct {
 	PendingTransactions     map[string]*Transaction // Pending transactions
 	PreviousTransaction     *Transaction            // Previous transaction
 	ChainLock               sync.RWMutex            // Blockchain lock
-	Chain                   Blockchain              // Blockchain
+	Chain                   Blockchain              // Blockchain, the main chain of block tree
+	Tree                    *BlockTree              // Main chain and its side branches
+	Index                   *ChainIndex             // Index of main chain
+	ForkChoice              ForkChoice              // Rule to pick main chain, nil for the longest chain
+	Light                   bool                    // Light node keeps only block headers instead of chain
+	Headers                 HeaderChain             // Block headers of light node
 	Listerner               *net.TCPListener        // TCP listener
+	Peers                   *PeerManager            // Connections to other nodes
 	MessageChannel          chan IncommingMessage   // Incomming message
+	MessagePolicy           MessagePolicy           // Decide if incomming message is delivered, nil to deliver all
+	Store                   Store                   // Persistent storage, nil if node lives only in memory
+	KeyFile                 string                  // File that key pair is kept in instead of store, empty if there is none
+	StoreErrorHandler       func(error)             // Called when writing through to store fails
+	Consensus               ConsensusConfig         // Timing rules of block generation
+	Throttle                ThrottleConfig          // Rate limit of block generators
+	ClusterLock             sync.RWMutex            // Cluster membership lock
+	Cluster                 string                  // Cluster of node, empty if node does not join any cluster
+	ClusterHeadPK           []byte                  // Public key of configured cluster head, empty to elect head
+	reputationCache         reputationCache         // Reputations derived from chain
+	sigCache                signatureCache          // Transactions whose signatures have been verified
+	routingTableSavedAt     time.Time               // Time of saving routing table into store
+	poolByKey               poolIndex               // Transactions of pool by requester and requestee
 }
 
 // NewNode ... Generate new node.
@@ -118,22 +180,117 @@ func NewNode(ip string, port int) (*Node, error) {
 		return nil, err
 	}
 
-	return &Node{
-		Keypair:                 kp,
+	n := &Node{
+		keypair:                 kp,
 		IP:                      ip,
 		Port:                    port,
 		RoutingTableLock:        sync.RWMutex{},
 		RoutingTable:            make(map[string]*RemoteNode),
 		TransactionsPoolLock:    sync.RWMutex{},
 		TransactionsPool:        make(map[string]*Transaction),
+		poolByKey:               make(poolIndex),
 		PendingTransactionsLock: sync.RWMutex{},
 		PendingTransactions:     make(map[string]*Transaction),
 		PreviousTransaction:     nil,
 		ChainLock:               sync.RWMutex{},
 		Chain:                   Blockchain{},
+		Tree:                    NewBlockTree(),
+		Index:                   NewChainIndex(),
 		Listerner:               new(net.TCPListener),
 		MessageChannel:          make(chan IncommingMessage),
-	}, nil
+	}
+
+	n.Peers = NewPeerManager(n.KeyPair)
+	n.Peers.ExpectedKey = n.expectedKeyOf
+
+	return n, nil
+}
+
+// expectedKeyOf ... Get public key that node at given address should prove, it's looked up in routing table.
+func (n *Node) expectedKeyOf(address string) []byte {
+	if b, rn := n.GetNodeByAddress(address); b {
+		return rn.PublicKey
+	}
+
+	return nil
+}
+
+// NewNodeWithStore ... Generate new node, and restore its states from store.
+// Every later mutation of node is written through to store.
+func NewNodeWithStore(ip string, port int, s Store) (*Node, error) {
+	n, err := NewNode(ip, port)
+	if err != nil {
+		return nil, err
+	}
+
+	kp, err := s.LoadKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	if kp != nil {
+		n.keypair = kp
+	} else {
+		err = s.SaveKeyPair(n.KeyPair())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bs, err := s.LoadBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	n.Chain = Blockchain{Blocks: bs}
+	n.indexBlocks(0)
+
+	headers, err := s.LoadHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	n.Headers = HeaderChain{Headers: headers}
+
+	for _, b := range bs {
+		n.Tree.Add(b)
+	}
+
+	pool, err := s.LoadTransactionsPool()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pool {
+		n.putIntoPool(&pool[i])
+	}
+
+	pendings, err := s.LoadPendingTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pendings {
+		n.PendingTransactions[Base58Encode(pendings[i].ID())] = &pendings[i]
+	}
+
+	nodes, err := s.LoadRoutingTable()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range nodes {
+		n.RoutingTable[Base58Encode(nodes[i].PublicKey)] = &nodes[i]
+	}
+
+	n.PreviousTransaction, err = s.LoadPrevTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	n.Store = s
+
+	return n, nil
 }
 
 // Run ... Run a simple TCP server.
@@ -173,53 +330,181 @@ func (n *Node) TCPAddr() (*net.TCPAddr, error) {
 	return net.ResolveTCPAddr("tcp", n.Addr())
 }
 
+// KeyPair ... Get key pair of node.
+func (n *Node) KeyPair() *KeyPair {
+	n.keypairLock.RLock()
+	defer n.keypairLock.RUnlock()
+
+	return n.keypair
+}
+
 // PublicKey ... Get public key of node.
 func (n *Node) PublicKey() []byte {
-	return n.Keypair.Public
+	return n.KeyPair().Public
 }
 
 // Sign ... Sign.
 func (n *Node) Sign(data []byte) []byte {
-	sig, _ := n.Keypair.Sign(data)
+	sig, _ := n.KeyPair().Sign(data)
 	return sig
 }
 
-// SignTransaction ... Sign transaction.
+// SignMessage ... Sign message as sender.
+func (n *Node) SignMessage(m Message) Message {
+	_ = m.Sign(n.KeyPair())
+	return m
+}
+
+// SignTransaction ... Sign transaction as requestee.
 func (n *Node) SignTransaction(t Transaction) Transaction {
-	t.Header.RequesteeSignature = n.Sign(SHA256(t.Meta))
+	t.Header.RequesteeSignature = n.Sign(t.RequesteeHash())
 	return t
 }
 
-// UpdateKeyPair ... Update key pair for node.
+// ConfirmTransaction ... Accept transaction as requestee, accepted number of requester advances.
+func (n *Node) ConfirmTransaction(t Transaction) Transaction {
+	t.Output.Accepted++
+	return n.SignTransaction(t)
+}
+
+// RejectTransaction ... Reject transaction as requestee, rejected number of requester advances.
+// Rejected transaction is recorded in chain as well, so it counts in reputation of requester.
+func (n *Node) RejectTransaction(t Transaction) Transaction {
+	t.Output.Rejected++
+	return n.SignTransaction(t)
+}
+
+// UpdateKeyPair ... Update key pair for node, it's rotated to a new key pair of the same key type,
+// so peers and chain follow it.
 func (n *Node) UpdateKeyPair() error {
-	kp, err := NewECDSAKeyPair()
+	kp, err := NewKeyPair(n.KeyPair().Type())
 	if err != nil {
 		return err
 	}
 
-	n.Keypair = kp
+	_, err = n.RotateKeyPair(kp)
 
-	return nil
+	return err
+}
+
+// SetKeyPair ... Replace key pair of node, e.g. by key pair imported from file.
+func (n *Node) SetKeyPair(kp *KeyPair) {
+	n.keypairLock.Lock()
+	n.keypair = kp
+	n.keypairLock.Unlock()
+
+	n.saveKeyPair()
+
+	// Connections are authenticated by old key.
+	n.Peers.Close()
+}
+
+// storeError ... Report error of writing through to store.
+func (n *Node) storeError(err error) {
+	if err != nil && n.StoreErrorHandler != nil {
+		n.StoreErrorHandler(err)
+	}
+}
+
+// saveKeyPair ... Write key pair through to store.
+// Key pair kept in key file is removed from store instead, it would be plaintext there.
+func (n *Node) saveKeyPair() {
+	if n.Store == nil {
+		return
+	}
+
+	if n.KeyFile != "" {
+		n.storeError(n.Store.SaveKeyPair(nil))
+		return
+	}
+
+	n.storeError(n.Store.SaveKeyPair(n.KeyPair()))
+}
+
+// saveRoutingTable ... Write routing table through to store.
+// NOTE: Caller should hold routing table lock.
+func (n *Node) saveRoutingTable() {
+	if n.Store == nil {
+		return
+	}
+
+	var nodes []RemoteNode
+	for _, rn := range n.RoutingTable {
+		nodes = append(nodes, *rn)
+	}
+
+	n.routingTableSavedAt = time.Now()
+
+	n.storeError(n.Store.SaveRoutingTable(nodes))
+}
+
+// saveTransactionsPool ... Write transactions pool through to store.
+// NOTE: Caller should hold transactions pool lock.
+func (n *Node) saveTransactionsPool() {
+	if n.Store == nil {
+		return
+	}
+
+	n.storeError(n.Store.SaveTransactionsPool(sortedTransactions(n.TransactionsPool)))
+}
+
+// savePendingTransactions ... Write pending transactions through to store.
+// NOTE: Caller should hold pending transactions lock.
+func (n *Node) savePendingTransactions() {
+	if n.Store == nil {
+		return
+	}
+
+	n.storeError(n.Store.SavePendingTransactions(sortedTransactions(n.PendingTransactions)))
+}
+
+// savePrevTransaction ... Write previous transaction through to store.
+func (n *Node) savePrevTransaction() {
+	if n.Store == nil {
+		return
+	}
+
+	n.storeError(n.Store.SavePrevTransaction(n.PreviousTransaction))
+}
+
+// sortedTransactions ... Collect transactions of map, sorted by time.
+func sortedTransactions(m map[string]*Transaction) TransactionSlice {
+	var trs TransactionSlice
+
+	for _, tr := range m {
+		trs = append(trs, *tr)
+	}
+
+	sort.Sort(trs)
+
+	return trs
 }
 
 // receivePacket ... Listen on binding address.
 func (n *Node) receivePacket(packetch chan Packet) {
 	for {
-		// TODO: Error handling
-		conn, _ := n.Listerner.AcceptTCP()
-
-		buf := make([]byte, MAX_RECV_PACKET)
-
-		// TODO: Error handling
-		bufLen, _ := conn.Read(buf)
+		conn, err := n.Listerner.AcceptTCP()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
 
-		p := Packet{
-			Content: buf[:bufLen],
-			Conn:    conn,
+			continue
 		}
 
-		// send packet to channel
-		packetch <- p
+		// Serve connection in its own goroutine, so a slow peer doesn't block others.
+		go func() {
+			pc, err := newPeerConn(conn, n.KeyPair(), false, nil)
+			if err != nil {
+				// Peer failed handshake.
+				return
+			}
+
+			pc.readLoop(func(id uint64, data []byte) {
+				// send packet to channel
+				packetch <- Packet{Content: data, Conn: pc, RequestID: id}
+			})
+		}()
 	}
 }
 
@@ -231,39 +516,33 @@ func (n *Node) processPacket(packetch chan Packet) {
 		err := m.UnmarshalJson(p.Content)
 		if err != nil {
 			// We just drop the malformed message
+			p.Conn.Respond(p.RequestID, nil)
 			continue
 		}
 
-		n.MessageChannel <- IncommingMessage{Content: m, Conn: p.Conn}
+		// Signed message with invalid signature is always dropped.
+		if m.IsSigned() && !m.VerifySignature() {
+			p.Conn.Respond(p.RequestID, nil)
+			continue
+		}
+
+		if n.MessagePolicy != nil && n.MessagePolicy.Accept(m, p.Conn.RemotePublicKey()) != nil {
+			p.Conn.Respond(p.RequestID, nil)
+			continue
+		}
+
+		n.MessageChannel <- newIncommingMessage(m, p)
 	}
 }
 
 // Send ... Send message to given node.
 func (n *Node) Send(address string, data []byte, handleCallback func([]byte) error) error {
-	addr, err := net.ResolveTCPAddr("tcp", address)
-	if err != nil {
-		return err
-	}
-
-	conn, err := net.DialTCP("tcp", nil, addr)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	_, err = conn.Write(data)
-	if err != nil {
-		return err
-	}
-
-	buf := make([]byte, MAX_RECV_PACKET)
-
-	buflen, err := conn.Read(buf)
+	resp, err := n.Peers.Request(address, data)
 	if err != nil {
 		return err
 	}
 
-	err = handleCallback(buf[:buflen])
+	err = handleCallback(resp)
 	if err != nil {
 		return err
 	}
@@ -271,13 +550,29 @@ func (n *Node) Send(address string, data []byte, handleCallback func([]byte) err
 	return nil
 }
 
-// Broadcast ... Broadcast data to all nodes.
+// Broadcast ... Broadcast data to all nodes, concurrently.
+// NOTE: handleCallback may be called from several goroutines at the same time.
 func (n *Node) Broadcast(data []byte, handleCallback func([]byte) error) {
 	_, nodes := n.GetNodesOfRoutingTable()
 
+	n.Multicast(nodes, data, handleCallback)
+}
+
+// Multicast ... Send data to given nodes concurrently.
+func (n *Node) Multicast(nodes []RemoteNode, data []byte, handleCallback func([]byte) error) {
+	var wg sync.WaitGroup
+
 	for _, rn := range nodes {
-		n.Send(rn.Addr(), data, handleCallback)
+		wg.Add(1)
+
+		go func(addr string) {
+			defer wg.Done()
+
+			n.Send(addr, data, handleCallback)
+		}(rn.Addr())
 	}
+
+	wg.Wait()
 }
 
 // CheckAndAddNodeToRoutingTable ... Check and add node to routing table.
@@ -290,14 +585,32 @@ func (n *Node) CheckAndAddNodeToRoutingTable(rn RemoteNode) {
 	}
 
 	n.RoutingTable[Base58Encode(rn.PublicKey)] = &rn
+
+	n.saveRoutingTable()
 }
 
 // UpdateNodeForGivenPublicKey ... Update node for given public key.
+// Routing table which only has lastseen of nodes changed is saved at most once in RoutingTableSavePeriod,
+// since every ping updates it.
 func (n *Node) UpdateNodeForGivenPublicKey(pk []byte, rn RemoteNode) {
 	n.RoutingTableLock.Lock()
 	defer n.RoutingTableLock.Unlock()
 
+	old := n.RoutingTable[Base58Encode(pk)]
+
 	n.RoutingTable[Base58Encode(pk)] = &rn
+
+	if old != nil && old.equalExceptLastseen(rn) && time.Since(n.routingTableSavedAt) < RoutingTableSavePeriod {
+		return
+	}
+
+	n.saveRoutingTable()
+}
+
+// equalExceptLastseen ... Test if node has the same key, address and cluster as given one.
+func (rn RemoteNode) equalExceptLastseen(other RemoteNode) bool {
+	return bytes.Equal(rn.PublicKey, other.PublicKey) && rn.Address == other.Address &&
+		rn.Cluster == other.Cluster && rn.Head == other.Head
 }
 
 // GetNodeByPublicKey ... Get node by public key.
@@ -314,6 +627,20 @@ func (n *Node) GetNodeByPublicKey(pk []byte) (bool, RemoteNode) {
 	return true, *rn
 }
 
+// GetNodeByAddress ... Get node by address.
+func (n *Node) GetNodeByAddress(address string) (bool, RemoteNode) {
+	n.RoutingTableLock.RLock()
+	defer n.RoutingTableLock.RUnlock()
+
+	for _, rn := range n.RoutingTable {
+		if rn.Address == address {
+			return true, *rn
+		}
+	}
+
+	return false, RemoteNode{}
+}
+
 // GetNodesOfRoutingTable ... Get nodes of routing table.
 func (n *Node) GetNodesOfRoutingTable() (int, []RemoteNode) {
 	n.RoutingTableLock.RLock()
@@ -346,35 +673,176 @@ func (n *Node) RemoveNodeByPublicKey(pk []byte) {
 	defer n.RoutingTableLock.Unlock()
 
 	delete(n.RoutingTable, Base58Encode(pk))
+
+	n.saveRoutingTable()
 }
 
 // CheckAndAddTransactionToPool ... Check and add transaction to pool.
 func (n *Node) CheckAndAddTransactionToPool(t Transaction) {
+	n.addTransactionsToPool(TransactionSlice{t})
+}
+
+// addTransactionsToPool ... Add transactions which are not in pool yet, pool is saved once for all of them.
+func (n *Node) addTransactionsToPool(trs TransactionSlice) {
+	// Only cluster heads keep transactions pool, other nodes forward transactions to their head.
+	if len(trs) == 0 || !n.IsClusterHead() {
+		return
+	}
+
 	n.TransactionsPoolLock.Lock()
 	defer n.TransactionsPoolLock.Unlock()
 
-	if n.TransactionsPool[Base58Encode(t.ID())] != nil {
-		return
+	added := false
+
+	for i := range trs {
+		if n.TransactionsPool[Base58Encode(trs[i].ID())] != nil {
+			continue
+		}
+
+		t := trs[i]
+		n.putIntoPool(&t)
+		added = true
 	}
 
-	n.TransactionsPool[Base58Encode(t.ID())] = &t
+	if added {
+		n.saveTransactionsPool()
+	}
+}
+
+// poolIndex ... Transactions of pool by key of their requester or requestee, then by id.
+type poolIndex map[string]map[string]*Transaction
+
+// putIntoPool ... Put transaction into pool, and index it by its requester and requestee.
+// NOTE: Caller should hold transactions pool lock.
+func (n *Node) putIntoPool(t *Transaction) {
+	id := Base58Encode(t.ID())
+	n.TransactionsPool[id] = t
+
+	for _, pk := range [][]byte{t.RequesterPK(), t.RequesteePK()} {
+		key := Base58Encode(pk)
+		if n.poolByKey[key] == nil {
+			n.poolByKey[key] = make(map[string]*Transaction)
+		}
+
+		n.poolByKey[key][id] = t
+	}
+}
+
+// dropFromPool ... Drop transaction from pool and its index, false if it's not in pool.
+// NOTE: Caller should hold transactions pool lock.
+func (n *Node) dropFromPool(idBytes []byte) bool {
+	id := Base58Encode(idBytes)
+
+	t := n.TransactionsPool[id]
+	if t == nil {
+		return false
+	}
+
+	delete(n.TransactionsPool, id)
+
+	for _, pk := range [][]byte{t.RequesterPK(), t.RequesteePK()} {
+		key := Base58Encode(pk)
+		delete(n.poolByKey[key], id)
+
+		if len(n.poolByKey[key]) == 0 {
+			delete(n.poolByKey, key)
+		}
+	}
+
+	return true
+}
+
+// poolTransactionsOf ... Get transactions of pool which given keys request or confirm.
+func (n *Node) poolTransactionsOf(pks ...[]byte) TransactionSlice {
+	n.TransactionsPoolLock.RLock()
+	defer n.TransactionsPoolLock.RUnlock()
+
+	seen := make(map[string]bool)
+
+	var trs TransactionSlice
+
+	for _, pk := range pks {
+		for id, t := range n.poolByKey[Base58Encode(pk)] {
+			if !seen[id] {
+				seen[id] = true
+				trs = append(trs, *t)
+			}
+		}
+	}
+
+	return trs
 }
 
 // VerifyTransaction ... Verify a given transaction.
 func (n *Node) VerifyTransaction(t Transaction) bool {
+	return n.CheckTransaction(t) == nil
+}
+
+// CheckTransaction ... Verify a given transaction, and tell why it's invalid.
+func (n *Node) CheckTransaction(t Transaction) error {
+	if !n.VerifyTransactionSignatures(t) {
+		return errors.New("Invalid transaction id or signature")
+	}
+
+	// Retired key could not request anymore.
+	if n.IsRotatedKey(t.RequesterPK()) {
+		return ErrRotatedKey
+	}
+
+	if t.IsKeyRotation() {
+		return n.checkKeyRotation(t)
+	}
+
 	if t.IsGenesisTransaction() {
 		// This is genesis transaction.
-		if t.Accepted() != 1 || t.Rejected() != 0 {
-			return false
-		}
+		return VerifyGenesisCredits(t)
+	}
+
+	// This is not genesis transaction, it should continue previous transaction of requester.
+	b, prev := n.PrevTransactionOf(t)
+	if !b {
+		return ErrUnknownPrevTransaction
+	}
 
-		return t.VerifyTransactionID() && t.VerifyRequesterSig() && t.VerifyRequesteeSig()
+	err := n.checkFork(t)
+	if err != nil {
+		return err
 	}
 
-	// This is not genesis transaction.
-	// TODO: Verify credits.
+	return VerifyCredits(prev, t)
+}
+
+// checkFork ... Check if another transaction claims the same previous transaction.
+// Transaction in chain always wins, and for transactions in pool the earlier one wins.
+func (n *Node) checkFork(t Transaction) error {
+	n.ChainLock.RLock()
+	b, loc := n.Index.LocateTransactionByPrevID(t.PreviousID())
+	var other Transaction
+	if b {
+		other = n.transactionAt(loc)
+	}
+	n.ChainLock.RUnlock()
+
+	if b && !bytes.Equal(other.ID(), t.ID()) {
+		return ErrForkedTransaction
+	}
+
+	inPool := n.IsInTransactionsPool(t.ID())
+
+	// Transaction claiming the same previous transaction is requested by the same key,
+	// or it's key rotation of that key, or it's continued by key rotation.
+	for _, other := range n.poolTransactionsOf(t.RequesterPK(), t.RequesteePK()) {
+		if other.IsGenesisTransaction() || bytes.Equal(other.ID(), t.ID()) || !bytes.Equal(other.PreviousID(), t.PreviousID()) {
+			continue
+		}
+
+		// Incomming transaction loses to the one we've seen.
+		if !inPool || other.IsEarlierThan(t) {
+			return ErrForkedTransaction
+		}
+	}
 
-	return t.VerifyTransactionID() && t.VerifyRequesterSig() && t.VerifyRequesteeSig()
+	return nil
 }
 
 // VerifyPendingTransaction ... Verify a pending transaction.
@@ -400,6 +868,8 @@ func (n *Node) SetGenesisTransaction(t Transaction) bool {
 
 	n.PreviousTransaction = &t
 
+	n.savePrevTransaction()
+
 	return true
 }
 
@@ -408,27 +878,41 @@ func (n *Node) UpdatePrevTransaction(t Transaction) bool {
 	if n.PrevTransaction() != nil {
 		n.PreviousTransaction = &t
 
+		n.savePrevTransaction()
+
 		return true
 	}
 
 	return false
 }
 
+// IsContinuationOfPrevTransaction ... Test if given transaction is valid and continues previous transaction of node.
+// It needs no ledger, so nodes outside of cluster heads can follow their own transactions.
+func (n *Node) IsContinuationOfPrevTransaction(t Transaction) bool {
+	prev := n.PrevTransaction()
+	if prev == nil || !bytes.Equal(t.RequesterPK(), n.PublicKey()) || !bytes.Equal(t.PreviousID(), prev.ID()) {
+		return false
+	}
+
+	if !t.VerifyTransactionID() || !t.VerifyRequesterSig() || !t.VerifyRequesteeSig() {
+		return false
+	}
+
+	return VerifyCredits(*prev, t) == nil
+}
+
 // PrevTransaction ... Get previous transaction.
 func (n *Node) PrevTransaction() *Transaction {
 	return n.PreviousTransaction
 }
 
-// PrevTransactionOf ... Get previoud transaction of given transaction.
+// PrevTransactionOf ... Get previoud transaction of given transaction, from chain or transactions pool.
 func (n *Node) PrevTransactionOf(tr Transaction) (bool, Transaction) {
-	n.ChainLock.RLock()
-	defer n.ChainLock.RUnlock()
-
-	if t, tr := n.Chain.GetTransactionByID(tr.PreviousID()); t {
+	if t, tr := n.GetTransactionByIDFromChain(tr.PreviousID()); t {
 		return true, tr
 	}
 
-	return false, Transaction{}
+	return n.GetTransactionByIDFromPool(tr.PreviousID())
 }
 
 // GetTransactionByIDFromChain ... Get transaction by id.
@@ -436,11 +920,91 @@ func (n *Node) GetTransactionByIDFromChain(id []byte) (bool, Transaction) {
 	n.ChainLock.RLock()
 	defer n.ChainLock.RUnlock()
 
-	if t, tr := n.Chain.GetTransactionByID(id); t {
-		return true, tr
+	found, loc := n.Index.LocateTransaction(id)
+	if !found {
+		return false, Transaction{}
+	}
+
+	return true, n.transactionAt(loc)
+}
+
+// GetMerkleProofFromChain ... Get block header and inclusion proof of transaction with given id.
+func (n *Node) GetMerkleProofFromChain(id []byte) (bool, BlockHeader, MerkleProof) {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.merkleProofOf(id)
+}
+
+// merkleProofOf ... Get block header and inclusion proof of transaction with given id.
+// NOTE: Caller should hold chain lock.
+func (n *Node) merkleProofOf(id []byte) (bool, BlockHeader, MerkleProof) {
+	found, loc := n.Index.LocateTransaction(id)
+	if !found {
+		return false, BlockHeader{}, MerkleProof{}
 	}
 
-	return false, Transaction{}
+	b := n.Chain.Blocks[loc.Height]
+	_, proof := b.MerkleProof(id)
+
+	return true, b.Header, proof
+}
+
+// ValidateChain ... Validate every block and transaction of chain.
+func (n *Node) ValidateChain() error {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	err := n.Chain.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = n.Chain.ValidateTiming(n.Consensus.minSpacing())
+	if err != nil {
+		return err
+	}
+
+	return n.Chain.ValidateThrottle(n.Throttle)
+}
+
+// appendBlock ... Append block to chain, it's written to store first,
+// so chain in memory never goes ahead of store.
+// NOTE: Caller should hold chain lock.
+func (n *Node) appendBlock(b Block) error {
+	if n.Store != nil {
+		err := n.Store.AppendBlock(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	n.Index.AddBlock(n.Chain.Height(), b)
+	n.Chain.AppendBlock(b)
+
+	// Block extends tip of main chain, so its previous block is always in tree.
+	n.Tree.Add(b)
+
+	return nil
+}
+
+// GetLastBlock ... Get the last block of chain.
+func (n *Node) GetLastBlock() (bool, Block) {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.Chain.LastBlock()
+}
+
+// GetBlocksOfChain ... Get blocks of chain.
+func (n *Node) GetBlocksOfChain() (int, BlockSlice) {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	bs := make(BlockSlice, len(n.Chain.Blocks))
+	copy(bs, n.Chain.Blocks)
+
+	return len(bs), bs
 }
 
 // GetTransactionByIDFromPool ... Get transaction by id.
@@ -462,15 +1026,8 @@ func (n *Node) GetTransactionsOfPool() (int, TransactionSlice) {
 	n.TransactionsPoolLock.RLock()
 	defer n.TransactionsPoolLock.RUnlock()
 
-	var trs TransactionSlice
-
 	// We should collect transactions in order.
-	for _, tr := range n.TransactionsPool {
-		trs = append(trs, *tr)
-	}
-
-	// Sort by time.
-	sort.Sort(trs)
+	trs := sortedTransactions(n.TransactionsPool)
 
 	return len(trs), trs
 }
@@ -492,7 +1049,29 @@ func (n *Node) RemoveTransactionByIDFromPool(id []byte) {
 	n.TransactionsPoolLock.Lock()
 	defer n.TransactionsPoolLock.Unlock()
 
-	delete(n.TransactionsPool, Base58Encode(id))
+	if !n.dropFromPool(id) {
+		return
+	}
+
+	n.saveTransactionsPool()
+}
+
+// RemoveTransactionsFromPool ... Remove transactions from pool, pool is saved once for all of them.
+func (n *Node) RemoveTransactionsFromPool(trs TransactionSlice) {
+	n.TransactionsPoolLock.Lock()
+	defer n.TransactionsPoolLock.Unlock()
+
+	removed := false
+
+	for _, t := range trs {
+		if n.dropFromPool(t.ID()) {
+			removed = true
+		}
+	}
+
+	if removed {
+		n.saveTransactionsPool()
+	}
 }
 
 // CheckAndAddPendingTransaction ... Check and add transaction to pending pool.
@@ -505,6 +1084,8 @@ func (n *Node) CheckAndAddPendingTransaction(t Transaction) {
 	}
 
 	n.PendingTransactions[Base58Encode(t.ID())] = &t
+
+	n.savePendingTransactions()
 }
 
 // GetPendingTransactionByID ... Get pending transaction by id.
@@ -526,15 +1107,8 @@ func (n *Node) GetPendingTransactions() (int, TransactionSlice) {
 	n.PendingTransactionsLock.RLock()
 	defer n.PendingTransactionsLock.RUnlock()
 
-	var trs TransactionSlice
-
 	// We should collect transactions in order.
-	for _, tr := range n.PendingTransactions {
-		trs = append(trs, *tr)
-	}
-
-	// Sort by time.
-	sort.Sort(trs)
+	trs := sortedTransactions(n.PendingTransactions)
 
 	return len(trs), trs
 }
@@ -556,7 +1130,13 @@ func (n *Node) RemovePendingTransactionByID(id []byte) {
 	n.PendingTransactionsLock.Lock()
 	defer n.PendingTransactionsLock.Unlock()
 
+	if n.PendingTransactions[Base58Encode(id)] == nil {
+		return
+	}
+
 	delete(n.PendingTransactions, Base58Encode(id))
+
+	n.savePendingTransactions()
 }
 
 // NewGenesisTransaction ... Generate new genesis transaction.
@@ -564,16 +1144,13 @@ func (n *Node) NewGenesisTransaction(data []byte) Transaction {
 	timestamp := int(time.Now().Unix())
 	timestampByte := UInt64ToBytes(uint64(timestamp))
 	id := SHA256(JoinBytes(n.PublicKey(), n.PublicKey(), timestampByte))
-	sig := n.Sign(SHA256(data))
 
 	h := TransactionHeader{
 		TransactionID:      id,
 		Timestamp:          timestamp,
 		PrevTransactionID:  id,
 		RequesterPublicKey: n.PublicKey(),
-		RequesterSignature: sig,
 		RequesteePublicKey: n.PublicKey(),
-		RequesteeSignature: sig,
 	}
 
 	txo := TXOutput{
@@ -581,9 +1158,39 @@ func (n *Node) NewGenesisTransaction(data []byte) Transaction {
 		Rejected: 0,
 	}
 
-	return Transaction{
+	t := Transaction{
 		Header: h,
 		Meta:   data,
 		Output: txo,
 	}
+
+	t.Header.RequesterSignature = n.Sign(t.RequesterHash())
+
+	return n.SignTransaction(t)
+}
+
+// NewPendingTransaction ... Generate new transaction to requestee, signed by node as requester.
+// It continues previous transaction of node, so genesis transaction should be generated first.
+func (n *Node) NewPendingTransaction(requesteePK []byte, data []byte) Transaction {
+	timestamp := int(time.Now().Unix())
+	timestampByte := UInt64ToBytes(uint64(timestamp))
+
+	h := TransactionHeader{
+		TransactionID:      SHA256(JoinBytes(n.PublicKey(), requesteePK, timestampByte)),
+		Timestamp:          timestamp,
+		PrevTransactionID:  n.PrevTransaction().ID(),
+		RequesterPublicKey: n.PublicKey(),
+		RequesteePublicKey: requesteePK,
+	}
+
+	// Output is the same as previous one, requestee advances it by its decision.
+	t := Transaction{
+		Header: h,
+		Meta:   data,
+		Output: n.PrevTransaction().Out(),
+	}
+
+	t.Header.RequesterSignature = n.Sign(t.RequesterHash())
+
+	return t
 }
@@ -47,3 +47,97 @@ func TestRemoteNodeMarshalJson(t *testing.T) {
 		panic(fmt.Errorf("(RemoteNode) MarshalJson()/UnmarshalJson() testing failed"))
 	}
 }
+
+// Test credit verification of non-genesis transactions.
+func TestCheckTransactionCredits(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	genesis := requester.NewGenesisTransaction([]byte("genesis"))
+	n.CheckAndAddTransactionToPool(genesis)
+	n.ProduceBlock()
+
+	t1 := GenSignedTransaction(requester, requestee, genesis, "t1")
+	if err := n.CheckTransaction(t1); err != nil {
+		panic(err)
+	}
+
+	inflated := t1
+	inflated.Output.Accepted++
+	inflated = requestee.SignTransaction(inflated)
+
+	if err := n.CheckTransaction(inflated); err != ErrInvalidCredits {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should reject invalid credits, got %v", err))
+	}
+
+	// Requester could not accept its own transaction.
+	self := GenSignedTransaction(requester, requester, genesis, "self")
+	if err := n.CheckTransaction(self); err != ErrSelfConfirmed {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should reject self confirmed transaction, got %v", err))
+	}
+
+	if err := VerifyCredits(genesis, self); err != ErrSelfConfirmed {
+		panic(fmt.Errorf("VerifyCredits() should reject self confirmed transaction, got %v", err))
+	}
+
+	// Credits can't be changed without requestee.
+	forged := t1
+	forged.Output.Rejected++
+
+	if n.VerifyTransaction(forged) {
+		panic(fmt.Errorf("(*Node) VerifyTransaction() accepted forged output"))
+	}
+
+	orphan := GenSignedTransaction(requester, requestee, GenRandomTransaction(), "orphan")
+	if err := n.CheckTransaction(orphan); err != ErrUnknownPrevTransaction {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should reject unknown previous transaction, got %v", err))
+	}
+
+	// Two transactions claim genesis as previous transaction.
+	n.CheckAndAddTransactionToPool(t1)
+
+	t2 := GenSignedTransaction(requester, requestee, genesis, "t2")
+	if err := n.CheckTransaction(t2); err != ErrForkedTransaction {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should reject fork, got %v", err))
+	}
+
+	// Previous transaction could be in pool.
+	t3 := GenSignedTransaction(requester, requestee, t1, "t3")
+	if err := n.CheckTransaction(t3); err != nil {
+		panic(err)
+	}
+
+	n.ProduceBlock()
+
+	if err := n.CheckTransaction(t2); err != ErrForkedTransaction {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should reject fork of sealed transaction, got %v", err))
+	}
+}
+
+// Test rejecting transaction as requestee.
+func TestRejectTransaction(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	genesis := requester.NewGenesisTransaction([]byte("genesis"))
+	requester.SetGenesisTransaction(genesis)
+	n.CheckAndAddTransactionToPool(genesis)
+
+	pending := requester.NewPendingTransaction(requestee.PublicKey(), []byte("data"))
+
+	if !requestee.VerifyPendingTransaction(pending) {
+		panic(fmt.Errorf("(*Node) NewPendingTransaction() testing failed"))
+	}
+
+	rejected := requestee.RejectTransaction(pending)
+
+	if err := n.CheckTransaction(rejected); err != nil {
+		panic(err)
+	}
+
+	if rejected.Accepted() != genesis.Accepted() || rejected.Rejected() != genesis.Rejected()+1 {
+		panic(fmt.Errorf("(*Node) RejectTransaction() should advance rejected number"))
+	}
+}
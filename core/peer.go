@@ -0,0 +1,361 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	RequestTimeout     = 10 * time.Second       // Max time of waiting for response
+	IdleTimeout        = 5 * time.Minute        // Idle connection is closed after this period
+	MaxConcurrentDials = 8                      // Max number of dials in progress
+	MinRedialBackoff   = 500 * time.Millisecond // Backoff after first failed dial
+	MaxRedialBackoff   = 30 * time.Second       // Backoff is doubled on every failed dial, up to this value
+
+	peerFrameHeaderSize = 9 // | request id ... 8 bytes | kind ... 1 byte |
+
+	frameRequest  byte = 0x00 // Frame carries request
+	frameResponse byte = 0x01 // Frame carries response
+)
+
+// ErrPeerConnClosed ... Connection is closed before response arrives.
+var ErrPeerConnClosed = errors.New("Peer connection is closed")
+
+// PeerConn ... Long-lived authenticated connection to peer, requests and responses are multiplexed on it by request id.
+// Every frame carries (sealed by session key):
+// | request id ... 8 bytes | kind ... 1 byte | data |
+type PeerConn struct {
+	conn      *net.TCPConn
+	session   *session               // Session established by handshake
+	writeLock sync.Mutex             // Frames are written as a whole, in order of sequence
+	sendSeq   uint64                 // Sequence number of next frame sent
+	recvSeq   uint64                 // Sequence number of next frame received
+	lock      sync.Mutex             // Lock of fields below
+	nextID    uint64                 // Next request id
+	pending   map[uint64]chan []byte // Requests waiting for response
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// newPeerConn ... Run handshake on TCP connection, expected is public key that peer should prove, nil to accept any.
+func newPeerConn(conn *net.TCPConn, kp *KeyPair, initiator bool, expected []byte) (*PeerConn, error) {
+	s, err := handshake(conn, kp, initiator, expected)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &PeerConn{
+		conn:    conn,
+		session: s,
+		pending: make(map[uint64]chan []byte),
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+// RemoteAddr ... Get address of peer.
+func (pc *PeerConn) RemoteAddr() string {
+	return pc.conn.RemoteAddr().String()
+}
+
+// RemotePublicKey ... Get public key proved by peer in handshake.
+func (pc *PeerConn) RemotePublicKey() []byte {
+	return pc.session.remotePK
+}
+
+// write ... Seal and write one frame.
+func (pc *PeerConn) write(id uint64, kind byte, data []byte) error {
+	pc.writeLock.Lock()
+	defer pc.writeLock.Unlock()
+
+	sealed := pc.session.send.Seal(nil, sequenceNonce(pc.sendSeq), JoinBytes(UInt64ToBytes(id), []byte{kind}, data), nil)
+	pc.sendSeq++
+
+	pc.conn.SetWriteDeadline(time.Now().Add(RequestTimeout))
+
+	return WriteFrame(pc.conn, sealed)
+}
+
+// read ... Read and open one frame.
+func (pc *PeerConn) read() ([]byte, error) {
+	sealed, err := ReadFrame(pc.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := pc.session.recv.Open(nil, sequenceNonce(pc.recvSeq), sealed, nil)
+	if err != nil {
+		return nil, err
+	}
+	pc.recvSeq++
+
+	return frame, nil
+}
+
+// Request ... Send request and wait for its response.
+func (pc *PeerConn) Request(data []byte, timeout time.Duration) ([]byte, error) {
+	ch := make(chan []byte, 1)
+
+	pc.lock.Lock()
+	id := pc.nextID
+	pc.nextID++
+	pc.pending[id] = ch
+	pc.lock.Unlock()
+
+	defer func() {
+		pc.lock.Lock()
+		delete(pc.pending, id)
+		pc.lock.Unlock()
+	}()
+
+	err := pc.write(id, frameRequest, data)
+	if err != nil {
+		pc.Close()
+		return nil, ErrPeerConnClosed
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-pc.closed:
+		return nil, ErrPeerConnClosed
+	case <-timer.C:
+		return nil, fmt.Errorf("Request to %s timed out", pc.RemoteAddr())
+	}
+}
+
+// Respond ... Send response of given request.
+func (pc *PeerConn) Respond(id uint64, data []byte) error {
+	return pc.write(id, frameResponse, data)
+}
+
+// readLoop ... Read frames until connection is closed.
+// Responses are delivered to waiting requests, requests are passed to handleRequest.
+func (pc *PeerConn) readLoop(handleRequest func(id uint64, data []byte)) {
+	defer pc.Close()
+
+	for {
+		pc.conn.SetReadDeadline(time.Now().Add(IdleTimeout))
+
+		frame, err := pc.read()
+		if err != nil || len(frame) < peerFrameHeaderSize {
+			return
+		}
+
+		id, _ := BytesToUInt64(frame[:8])
+		kind := frame[8]
+		data := frame[peerFrameHeaderSize:]
+
+		switch kind {
+		case frameRequest:
+			handleRequest(id, data)
+		case frameResponse:
+			// Request gets one response, duplicate or late ones are dropped so reading never blocks.
+			pc.lock.Lock()
+			if ch := pc.pending[id]; ch != nil {
+				delete(pc.pending, id)
+
+				select {
+				case ch <- data:
+				default:
+				}
+			}
+			pc.lock.Unlock()
+		}
+	}
+}
+
+// Closed ... Test if connection is closed.
+func (pc *PeerConn) Closed() bool {
+	select {
+	case <-pc.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close ... Close connection, requests in flight fail with ErrPeerConnClosed.
+func (pc *PeerConn) Close() error {
+	var err error
+
+	pc.closeOnce.Do(func() {
+		close(pc.closed)
+		err = pc.conn.Close()
+	})
+
+	return err
+}
+
+// redialState ... Backoff state of address that failed to dial.
+type redialState struct {
+	backoff time.Duration // Current backoff
+	next    time.Time     // Don't dial before this time
+}
+
+// PeerManager ... Keep long-lived connections to peers.
+type PeerManager struct {
+	KeyPair     func() *KeyPair             // Key pair that we prove in handshake
+	ExpectedKey func(address string) []byte // Public key that peer at address should prove, nil to accept any
+	lock        sync.Mutex
+	conns       map[string]*PeerConn     // Connections by address
+	dialing     map[string]chan struct{} // Dials in progress by address, closed when dial is done
+	redials     map[string]*redialState  // Backoff of addresses that failed to dial
+	dialSem     chan struct{}            // Bound concurrent dials
+}
+
+// NewPeerManager ... Generate new peer manager.
+func NewPeerManager(kp func() *KeyPair) *PeerManager {
+	return &PeerManager{
+		KeyPair: kp,
+		conns:   make(map[string]*PeerConn),
+		dialing: make(map[string]chan struct{}),
+		redials: make(map[string]*redialState),
+		dialSem: make(chan struct{}, MaxConcurrentDials),
+	}
+}
+
+// Get ... Get connection to address, dial if there is none.
+// The second return value tells if connection is newly dialed.
+func (pm *PeerManager) Get(address string) (*PeerConn, bool, error) {
+	for {
+		pm.lock.Lock()
+
+		if pc := pm.conns[address]; pc != nil && !pc.Closed() {
+			pm.lock.Unlock()
+			return pc, false, nil
+		}
+
+		if done := pm.dialing[address]; done != nil {
+			// Someone else is dialing, wait and check again.
+			pm.lock.Unlock()
+			<-done
+			continue
+		}
+
+		if rs := pm.redials[address]; rs != nil && time.Now().Before(rs.next) {
+			pm.lock.Unlock()
+			return nil, false, fmt.Errorf("%s is unreachable, retry after %s", address, rs.next.Format(time.RFC3339))
+		}
+
+		done := make(chan struct{})
+		pm.dialing[address] = done
+		pm.lock.Unlock()
+
+		pc, err := pm.dial(address)
+
+		pm.lock.Lock()
+		delete(pm.dialing, address)
+		close(done)
+
+		if err != nil {
+			rs := pm.redials[address]
+			if rs == nil {
+				rs = &redialState{backoff: MinRedialBackoff}
+				pm.redials[address] = rs
+			} else if rs.backoff *= 2; rs.backoff > MaxRedialBackoff {
+				rs.backoff = MaxRedialBackoff
+			}
+			rs.next = time.Now().Add(rs.backoff)
+
+			pm.lock.Unlock()
+			return nil, false, err
+		}
+
+		delete(pm.redials, address)
+		pm.conns[address] = pc
+		pm.lock.Unlock()
+
+		return pc, true, nil
+	}
+}
+
+// dial ... Dial address, number of concurrent dials is bounded.
+func (pm *PeerManager) dial(address string) (*PeerConn, error) {
+	pm.dialSem <- struct{}{}
+	defer func() { <-pm.dialSem }()
+
+	// Unreachable peer should not hold slot of dial for long.
+	dialer := net.Dialer{Timeout: RequestTimeout}
+
+	c, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := c.(*net.TCPConn)
+
+	var expected []byte
+	if pm.ExpectedKey != nil {
+		expected = pm.ExpectedKey(address)
+	}
+
+	pc, err := newPeerConn(conn, pm.KeyPair(), true, expected)
+	if err != nil {
+		return nil, err
+	}
+
+	// Peers don't send requests on connections we dialed.
+	go func() {
+		pc.readLoop(func(uint64, []byte) {})
+		pm.remove(address, pc)
+	}()
+
+	return pc, nil
+}
+
+// remove ... Remove closed connection.
+func (pm *PeerManager) remove(address string, pc *PeerConn) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	if pm.conns[address] == pc {
+		delete(pm.conns, address)
+	}
+}
+
+// Request ... Send request to address and wait for its response.
+// A request on a stale connection (e.g. closed by peer for idle) is retried once on a new connection.
+func (pm *PeerManager) Request(address string, data []byte) ([]byte, error) {
+	for {
+		pc, fresh, err := pm.Get(address)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := pc.Request(data, RequestTimeout)
+		if err == ErrPeerConnClosed && !fresh {
+			continue
+		}
+
+		return resp, err
+	}
+}
+
+// Drop ... Close connection to address, the next request dials it again.
+func (pm *PeerManager) Drop(address string) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	if pc := pm.conns[address]; pc != nil {
+		pc.Close()
+		delete(pm.conns, address)
+	}
+}
+
+// Close ... Close all connections.
+func (pm *PeerManager) Close() {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	for address, pc := range pm.conns {
+		pc.Close()
+		delete(pm.conns, address)
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Run node which echoes every message back.
+func RunEchoNode() (*Node, string) {
+	n, err := NewNode("127.0.0.1", 0)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := n.Run(); err != nil {
+		panic(err)
+	}
+
+	go func() {
+		for im := range n.MessageChannel {
+			im.Reply(im.Content.Data)
+			im.Close()
+		}
+	}()
+
+	return n, n.Listerner.Addr().(*net.TCPAddr).String()
+}
+
+// Test multiplexing concurrent requests on one connection.
+func TestPeerManagerMultiplexing(t *testing.T) {
+	server, addr := RunEchoNode()
+	defer server.Listerner.Close()
+
+	client, _ := NewNode("127.0.0.1", 0)
+	defer client.Peers.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			data := []byte(fmt.Sprintf("request-%d", i))
+			m := Message{Type: Ping, Data: data}
+			mjson, _ := m.MarshalJson()
+
+			err := client.Send(addr, mjson, func(resp []byte) error {
+				if !bytes.Equal(resp, data) {
+					return fmt.Errorf("Response mismatch: %s != %s", resp, data)
+				}
+
+				return nil
+			})
+
+			if err != nil {
+				panic(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if len(client.Peers.conns) != 1 {
+		panic(fmt.Errorf("(*PeerManager) should keep one connection per peer, got %d", len(client.Peers.conns)))
+	}
+
+	// Connection closed by peer is re-dialed.
+	pc, _, _ := client.Peers.Get(addr)
+	pc.Close()
+
+	if _, err := client.Peers.Request(addr, []byte("{}")); err != nil {
+		panic(err)
+	}
+}
+
+// Test backoff of unreachable peer.
+func TestPeerManagerBackoff(t *testing.T) {
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := l.Addr().String()
+	l.Close()
+
+	kp, _ := NewECDSAKeyPair()
+	pm := NewPeerManager(func() *KeyPair { return kp })
+
+	if _, err := pm.Request(addr, nil); err == nil {
+		panic(fmt.Errorf("(*PeerManager) Request() to closed port should fail"))
+	}
+
+	_, err := pm.Request(addr, nil)
+	if err == nil || !strings.Contains(err.Error(), "unreachable") {
+		panic(fmt.Errorf("(*PeerManager) Request() should back off, got %v", err))
+	}
+}
+
+// Test peer answering with duplicate and unknown responses.
+func TestPeerConnDuplicateResponses(t *testing.T) {
+	l, _ := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	defer l.Close()
+
+	serverKP, _ := NewECDSAKeyPair()
+	clientKP, _ := NewECDSAKeyPair()
+
+	go func() {
+		conn, err := l.AcceptTCP()
+		if err != nil {
+			return
+		}
+
+		pc, err := newPeerConn(conn, serverKP, false, nil)
+		if err != nil {
+			return
+		}
+		defer pc.Close()
+
+		for {
+			frame, err := pc.read()
+			if err != nil {
+				return
+			}
+
+			id, _ := BytesToUInt64(frame[:8])
+
+			pc.Respond(id, frame[peerFrameHeaderSize:])
+			pc.Respond(id, []byte("duplicate"))
+			pc.Respond(id+100, []byte("unknown"))
+		}
+	}()
+
+	conn, _ := net.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+
+	pc, err := newPeerConn(conn, clientKP, true, nil)
+	if err != nil {
+		panic(err)
+	}
+	defer pc.Close()
+
+	go pc.readLoop(func(uint64, []byte) {})
+
+	for i := 0; i < 10; i++ {
+		data := []byte(fmt.Sprintf("request-%d", i))
+
+		resp, err := pc.Request(data, RequestTimeout)
+		if err != nil || !bytes.Equal(resp, data) {
+			panic(fmt.Errorf("(*PeerConn) Request() should get the first response, got %s, %v", resp, err))
+		}
+	}
+}
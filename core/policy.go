@@ -0,0 +1,113 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MessagePolicy ... Decide if incomming message should be delivered.
+// sender is public key proved by connection, message is dropped if it returns error.
+type MessagePolicy interface {
+	Accept(m Message, sender []byte) error
+}
+
+// MessagePolicyFunc ... Use function as message policy.
+type MessagePolicyFunc func(m Message, sender []byte) error
+
+// Accept ... Implement MessagePolicy.
+func (f MessagePolicyFunc) Accept(m Message, sender []byte) error {
+	return f(m, sender)
+}
+
+// SignaturePolicy ... Reject unsigned messages of given types, and replayed signed messages.
+// NOTE: Signature itself is verified before any policy.
+type SignaturePolicy struct {
+	RequireSigned map[byte]bool // Message types that should be signed
+	MaxAge        time.Duration // Signed message is rejected if its timestamp is off by more than this
+
+	lock      sync.Mutex
+	seen      map[string]int // Nonces seen within MaxAge, with their timestamp
+	lastPrune time.Time
+}
+
+// NewSignaturePolicy ... Generate new signature policy, messages of given types should be signed.
+func NewSignaturePolicy(maxAge time.Duration, types ...byte) *SignaturePolicy {
+	p := &SignaturePolicy{
+		RequireSigned: make(map[byte]bool),
+		MaxAge:        maxAge,
+		seen:          make(map[string]int),
+		lastPrune:     time.Now(),
+	}
+
+	for _, t := range types {
+		p.RequireSigned[t] = true
+	}
+
+	return p
+}
+
+// Accept ... Implement MessagePolicy.
+func (p *SignaturePolicy) Accept(m Message, sender []byte) error {
+	if !m.IsSigned() {
+		if p.RequireSigned[m.Type] {
+			return errors.New("Message should be signed")
+		}
+
+		return nil
+	}
+
+	now := time.Now()
+	maxAge := int(p.MaxAge / time.Second)
+
+	if m.Timestamp < int(now.Unix())-maxAge || m.Timestamp > int(now.Unix())+maxAge {
+		return errors.New("Message is expired")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// Nonces older than MaxAge are useless, since their messages are rejected as expired.
+	if now.Sub(p.lastPrune) > p.MaxAge {
+		for nonce, ts := range p.seen {
+			if ts < int(now.Unix())-maxAge {
+				delete(p.seen, nonce)
+			}
+		}
+
+		p.lastPrune = now
+	}
+
+	key := Base58Encode(JoinBytes(m.Sender, m.Nonce))
+	if _, ok := p.seen[key]; ok {
+		return errors.New("Message is replayed")
+	}
+
+	p.seen[key] = m.Timestamp
+
+	return nil
+}
+
+// SenderPolicy ... Reject signed messages whose sender is not the peer at the other end of connection,
+// i.e. messages can't be relayed.
+var SenderPolicy = MessagePolicyFunc(func(m Message, sender []byte) error {
+	if m.IsSigned() && !bytes.Equal(m.Sender, sender) {
+		return errors.New("Message is not signed by peer")
+	}
+
+	return nil
+})
+
+// ChainPolicies ... Combine policies, message should be accepted by all of them.
+func ChainPolicies(policies ...MessagePolicy) MessagePolicy {
+	return MessagePolicyFunc(func(m Message, sender []byte) error {
+		for _, p := range policies {
+			if err := p.Accept(m, sender); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
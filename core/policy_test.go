@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Test rejecting unsigned and replayed messages.
+func TestSignaturePolicy(t *testing.T) {
+	kp, _ := NewECDSAKeyPair()
+
+	p := NewSignaturePolicy(time.Minute, SyncNodes)
+
+	if p.Accept(NewSyncNodesMessage(nil), nil) == nil {
+		panic(fmt.Errorf("(*SignaturePolicy) Accept() accepted unsigned message"))
+	}
+
+	if p.Accept(NewPingMessage(kp.Public, "127.0.0.1:3000"), nil) != nil {
+		panic(fmt.Errorf("(*SignaturePolicy) Accept() should accept unsigned message of other types"))
+	}
+
+	m := NewSyncNodesMessage(GenRandomRemoteNodes(2))
+	_ = m.Sign(kp)
+
+	if err := p.Accept(m, kp.Public); err != nil {
+		panic(err)
+	}
+
+	if p.Accept(m, kp.Public) == nil {
+		panic(fmt.Errorf("(*SignaturePolicy) Accept() accepted replayed message"))
+	}
+
+	expired := NewSyncNodesMessage(nil)
+	_ = expired.Sign(kp)
+	expired.Timestamp -= 120
+
+	if p.Accept(expired, kp.Public) == nil {
+		panic(fmt.Errorf("(*SignaturePolicy) Accept() accepted expired message"))
+	}
+
+	other, _ := NewECDSAKeyPair()
+
+	relayed := NewSyncNodesMessage(nil)
+	_ = relayed.Sign(kp)
+
+	if ChainPolicies(SenderPolicy, p).Accept(relayed, other.Public) == nil {
+		panic(fmt.Errorf("SenderPolicy accepted relayed message"))
+	}
+}
+
+// Test dropping message rejected by policy before delivery.
+func TestNodeMessagePolicy(t *testing.T) {
+	server, addr := RunEchoNode()
+	defer server.Listerner.Close()
+
+	server.MessagePolicy = NewSignaturePolicy(time.Minute, Ping)
+
+	client, _ := NewNode("127.0.0.1", 0)
+	defer client.Peers.Close()
+
+	send := func(m Message) string {
+		var resp []byte
+
+		mjson, _ := m.MarshalJson()
+		_ = client.Send(addr, mjson, func(data []byte) error { resp = data; return nil })
+
+		return string(resp)
+	}
+
+	m := NewPingMessage(client.PublicKey(), client.Addr())
+
+	if send(m) != "" {
+		panic(fmt.Errorf("Unsigned message should be dropped"))
+	}
+
+	signed := client.SignMessage(m)
+
+	if send(signed) != string(m.Data) {
+		panic(fmt.Errorf("Signed message should be delivered"))
+	}
+
+	// Tampered signed message.
+	signed.Data = []byte("{}")
+
+	if send(signed) != "" {
+		panic(fmt.Errorf("Message with invalid signature should be dropped"))
+	}
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"time"
+)
+
+// NewBlock ... Generate new block on top of given previous block, signed by node.
+func (n *Node) NewBlock(prevBlockID []byte, trs TransactionSlice) (Block, error) {
+	h := BlockHeader{
+		GeneratorID: n.PublicKey(),
+		PrevBlockID: prevBlockID,
+		MerkleRoot:  trs.MerkleRoot(),
+		Timestamp:   int(time.Now().Unix()),
+	}
+
+	b := Block{
+		Header:       h,
+		Transactions: trs,
+	}
+
+	err := b.Sign(n.KeyPair())
+	if err != nil {
+		return Block{}, err
+	}
+
+	return b, nil
+}
+
+// collectTransactionsForBlock ... Collect verified transactions of pool which are not in chain yet.
+func (n *Node) collectTransactionsForBlock() TransactionSlice {
+	_, pool := n.GetTransactionsOfPool()
+
+	var trs TransactionSlice
+	var dropped TransactionSlice
+
+	// Dropped transactions leave pool at once, so pool is saved only once.
+	defer func() { n.RemoveTransactionsFromPool(dropped) }()
+
+	for _, t := range pool {
+		if b, _ := n.GetTransactionByIDFromChain(t.ID()); b {
+			// It has been sealed already, we just drop it.
+			dropped = append(dropped, t)
+			continue
+		}
+
+		err := n.CheckTransaction(t)
+		if err == ErrUnknownPrevTransaction {
+			// Previous transaction may come later.
+			continue
+		}
+
+		if err != nil {
+			// It will never be valid.
+			dropped = append(dropped, t)
+			continue
+		}
+
+		trs = append(trs, t)
+	}
+
+	return orderByPrevTransaction(trs)
+}
+
+// orderByPrevTransaction ... Order transactions so that every one follows its previous transaction.
+// Timestamps are in seconds, so e.g. key rotation and the next transaction could tie.
+func orderByPrevTransaction(trs TransactionSlice) TransactionSlice {
+	// Transactions could share id, e.g. forged copy of transaction, so they're counted.
+	left := make(map[string]int)
+	for _, t := range trs {
+		left[Base58Encode(t.ID())]++
+	}
+
+	placed := make([]bool, len(trs))
+
+	var ordered TransactionSlice
+
+	for len(ordered) < len(trs) {
+		progressed := false
+
+		for i, t := range trs {
+			if placed[i] || (!t.IsGenesisTransaction() && left[Base58Encode(t.PreviousID())] > 0) {
+				continue
+			}
+
+			ordered = append(ordered, t)
+			left[Base58Encode(t.ID())]--
+			placed[i] = true
+			progressed = true
+		}
+
+		// Previous transactions never form a cycle, since ids are hashes.
+		if !progressed {
+			break
+		}
+	}
+
+	return ordered
+}
+
+// ProduceBlock ... Seal verified transactions of pool into a new block and append it to chain.
+func (n *Node) ProduceBlock() (bool, Block) {
+	// Only cluster heads produce blocks.
+	if !n.IsClusterHead() {
+		return false, Block{}
+	}
+
+	trs := n.collectTransactionsForBlock()
+	if len(trs) == 0 {
+		return false, Block{}
+	}
+
+	n.ChainLock.Lock()
+
+	// Generator should keep minimum spacing between its own blocks, and within its block budget.
+	if !n.canProduceBlock(int(time.Now().Unix())) {
+		n.ChainLock.Unlock()
+		return false, Block{}
+	}
+
+	var prevBlockID []byte
+	if b, last := n.Chain.LastBlock(); b {
+		prevBlockID = last.ID()
+	}
+
+	block, err := n.NewBlock(prevBlockID, trs)
+	if err != nil {
+		n.ChainLock.Unlock()
+		return false, Block{}
+	}
+
+	err = n.appendBlock(block)
+
+	n.ChainLock.Unlock()
+
+	if err != nil {
+		n.storeError(err)
+		return false, Block{}
+	}
+
+	n.RemoveTransactionsFromPool(trs)
+
+	return true, block
+}
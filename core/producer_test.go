@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Test sealing transactions pool into blocks.
+func TestProduceBlock(t *testing.T) {
+	n, err := NewNode("127.0.0.1", 0)
+	if err != nil {
+		panic(err)
+	}
+
+	if b, _ := n.ProduceBlock(); b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should not seal empty pool"))
+	}
+
+	// Invalid transaction should stay out of chain.
+	n.CheckAndAddTransactionToPool(GenRandomTransaction())
+	n.CheckAndAddTransactionToPool(n.NewGenesisTransaction([]byte("genesis")))
+
+	b, block := n.ProduceBlock()
+	if !b || len(block.Transactions) != 1 {
+		panic(fmt.Errorf("(*Node) ProduceBlock() testing failed"))
+	}
+
+	if !block.VerifySignature() {
+		panic(fmt.Errorf("(*Node) ProduceBlock() generated invalid signature"))
+	}
+
+	if c, _ := n.GetTransactionsOfPool(); c != 0 {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should remove sealed and invalid transactions from pool"))
+	}
+
+	// Sealed transaction gossiped back to pool should not be sealed twice.
+	n.CheckAndAddTransactionToPool(block.Transactions[0])
+
+	if b, _ := n.ProduceBlock(); b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() sealed transaction twice"))
+	}
+
+	m, err := NewNode("127.0.0.1", 0)
+	if err != nil {
+		panic(err)
+	}
+
+	n.CheckAndAddTransactionToPool(m.NewGenesisTransaction([]byte("genesis")))
+
+	b, next := n.ProduceBlock()
+	if !b || !bytes.Equal(next.Header.PrevBlockID, block.ID()) {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should link to previous block"))
+	}
+
+	if h, _ := n.GetBlocksOfChain(); h != 2 {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should append blocks to chain"))
+	}
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// Reputation ... Reputation of node, derived from transactions it requested in chain.
+// Score is the expected value of beta distribution, (accepted + 1) / (accepted + rejected + 2),
+// so a node without history scores 0.5.
+type Reputation struct {
+	PublicKey    []byte  // Public key of node
+	Accepted     int     // Accepted number of its last transaction
+	Rejected     int     // Rejected number of its last transaction
+	Transactions int     // Number of transactions requested by it in chain
+	Score        float64 // Score in [0, 1]
+}
+
+// ReputationJSONImpl ...
+type ReputationJSONImpl struct {
+	PublicKey    string  `json:"public_key"`
+	Accepted     int     `json:"accepted"`
+	Rejected     int     `json:"rejected"`
+	Transactions int     `json:"transactions"`
+	Score        float64 `json:"score"`
+}
+
+// NewReputation ... Generate reputation from accepted and rejected numbers.
+func NewReputation(pk []byte, accepted, rejected, transactions int) Reputation {
+	return Reputation{
+		PublicKey:    pk,
+		Accepted:     accepted,
+		Rejected:     rejected,
+		Transactions: transactions,
+		Score:        float64(accepted+1) / float64(accepted+rejected+2),
+	}
+}
+
+// MarshalJSON ... Serialize Reputation into Json.
+func (r Reputation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&ReputationJSONImpl{
+		PublicKey:    Base58Encode(r.PublicKey),
+		Accepted:     r.Accepted,
+		Rejected:     r.Rejected,
+		Transactions: r.Transactions,
+		Score:        r.Score,
+	})
+}
+
+// UnmarshalJSON ... Read Reputation from Json.
+func (r *Reputation) UnmarshalJSON(data []byte) error {
+	var rr ReputationJSONImpl
+
+	err := json.Unmarshal(data, &rr)
+	if err != nil {
+		return err
+	}
+
+	r.PublicKey = Base58Decode(rr.PublicKey)
+	r.Accepted = rr.Accepted
+	r.Rejected = rr.Rejected
+	r.Transactions = rr.Transactions
+	r.Score = rr.Score
+
+	return nil
+}
+
+// Reputations ... Compute reputations of every requester in chain, by public key.
+// The output of the last transaction of requester carries its counters.
+func (bc Blockchain) Reputations() map[string]Reputation {
+	table := make(map[string]Reputation)
+
+	for _, b := range bc.Blocks {
+		for _, t := range b.Transactions {
+			pk := Base58Encode(t.RequesterPK())
+
+			// Reputation of rotated key moves to its new key.
+			count := table[pk].Transactions
+			if t.IsKeyRotation() {
+				oldPK := Base58Encode(t.RequesteePK())
+				count = table[oldPK].Transactions
+				delete(table, oldPK)
+			}
+
+			table[pk] = NewReputation(t.RequesterPK(), t.Accepted(), t.Rejected(), count+1)
+		}
+	}
+
+	return table
+}
+
+// reputationCache ... Reputations computed for chain with given tip, tip id commits to the whole chain.
+type reputationCache struct {
+	lock  sync.Mutex
+	tipID []byte
+	table map[string]Reputation
+}
+
+// of ... Get reputations of chain, they're recomputed only if chain changes.
+func (c *reputationCache) of(bc Blockchain) map[string]Reputation {
+	var tipID []byte
+	if b, last := bc.LastBlock(); b {
+		tipID = last.ID()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.table == nil || !bytes.Equal(c.tipID, tipID) {
+		c.table = bc.Reputations()
+		c.tipID = tipID
+	}
+
+	return c.table
+}
+
+// reputations ... Get reputations of chain, they're recomputed only if chain changes.
+func (n *Node) reputations() map[string]Reputation {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.reputationCache.of(n.Chain)
+}
+
+// ReputationOf ... Get reputation of node with given public key.
+func (n *Node) ReputationOf(pk []byte) Reputation {
+	if r, ok := n.reputations()[Base58Encode(pk)]; ok {
+		return r
+	}
+
+	return NewReputation(pk, 0, 0, 0)
+}
+
+// GetReputations ... Get reputations of every requester in chain, the most trusted first.
+func (n *Node) GetReputations() []Reputation {
+	var rs []Reputation
+
+	for _, r := range n.reputations() {
+		rs = append(rs, r)
+	}
+
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Score != rs[j].Score {
+			return rs[i].Score > rs[j].Score
+		}
+
+		return bytes.Compare(rs[i].PublicKey, rs[j].PublicKey) < 0
+	})
+
+	return rs
+}
+
+// IsTrusted ... Test if node with given public key scores at least threshold.
+func (n *Node) IsTrusted(pk []byte, threshold float64) bool {
+	return n.ReputationOf(pk).Score >= threshold
+}
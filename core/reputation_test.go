@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Test reputation derived from chain.
+func TestReputation(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	if r := n.ReputationOf(requester.PublicKey()); r.Score != 0.5 || r.Transactions != 0 {
+		panic(fmt.Errorf("(*Node) ReputationOf() should be neutral for unknown node"))
+	}
+
+	prev := requester.NewGenesisTransaction([]byte("genesis"))
+	n.CheckAndAddTransactionToPool(prev)
+	n.ProduceBlock()
+
+	if r := n.ReputationOf(requester.PublicKey()); r.Accepted != 1 || r.Transactions != 1 {
+		panic(fmt.Errorf("(*Node) ReputationOf() should count genesis transaction"))
+	}
+
+	for i := 0; i < 5; i++ {
+		prev = requestee.RejectTransaction(GenRequestedTransaction(requester, requestee, prev, fmt.Sprintf("%d", i)))
+		n.CheckAndAddTransactionToPool(prev)
+	}
+
+	// Reputation is derived from chain only, not from pool.
+	if !n.IsTrusted(requester.PublicKey(), 0.5) {
+		panic(fmt.Errorf("(*Node) IsTrusted() should ignore transactions pool"))
+	}
+
+	n.ProduceBlock()
+
+	r := n.ReputationOf(requester.PublicKey())
+	if r.Transactions != 6 || r.Rejected != 5 || r.Score != 2.0/8.0 {
+		panic(fmt.Errorf("(*Node) ReputationOf() testing failed: %+v", r))
+	}
+
+	if n.IsTrusted(requester.PublicKey(), 0.3) {
+		panic(fmt.Errorf("(*Node) IsTrusted() should not trust node with bad record"))
+	}
+
+	rs := n.GetReputations()
+	if len(rs) != 1 || !bytes.Equal(rs[0].PublicKey, r.PublicKey) || rs[0].Score != r.Score {
+		panic(fmt.Errorf("(*Node) GetReputations() testing failed"))
+	}
+
+	rjson, _ := r.MarshalJSON()
+
+	var rr Reputation
+	if err := rr.UnmarshalJSON(rjson); err != nil || !bytes.Equal(rr.PublicKey, r.PublicKey) || rr.Score != r.Score || rr.Transactions != r.Transactions {
+		panic(fmt.Errorf("(Reputation) MarshalJSON() testing failed"))
+	}
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// KeyRotationMeta ... Meta data of key rotation transaction.
+var KeyRotationMeta = []byte("microchain/key-rotation")
+
+// Errors of key rotation.
+var (
+	ErrInvalidKeyRotation = errors.New("Key rotation does not continue old key")
+	ErrRotatedKey         = errors.New("Key has been rotated")
+)
+
+// NOTE: Key rotation is a transaction requested by the new key, to the old key as requestee.
+// New key signs it as requester, which proves it owns the new key, and old key signs it as requestee,
+// which hands identity over to new key. It claims the last transaction of old key as its previous one,
+// and carries credits of old key unchanged, so transactions of new key continue the chain of old key.
+// Old key without transactions rotates in a genesis transaction of new key.
+
+// IsKeyRotation ... Test if it's key rotation transaction.
+func (t Transaction) IsKeyRotation() bool {
+	return bytes.Equal(t.Meta, KeyRotationMeta) && !bytes.Equal(t.RequesterPK(), t.RequesteePK())
+}
+
+// VerifyKeyRotation ... Verify that key rotation continues given previous transaction of old key,
+// prev is nil if old key has no transaction.
+func VerifyKeyRotation(prev *Transaction, t Transaction) error {
+	if !t.IsKeyRotation() {
+		return ErrInvalidKeyRotation
+	}
+
+	if prev == nil {
+		if !t.IsGenesisTransaction() || t.Accepted() != 1 || t.Rejected() != 0 {
+			return ErrInvalidKeyRotation
+		}
+
+		return nil
+	}
+
+	if t.IsGenesisTransaction() || !bytes.Equal(t.PreviousID(), prev.ID()) || !bytes.Equal(prev.RequesterPK(), t.RequesteePK()) {
+		return ErrInvalidKeyRotation
+	}
+
+	if t.Timestamp() < prev.Timestamp() {
+		return ErrInvalidKeyRotation
+	}
+
+	// Rotation is not decided by anyone, credits stay the same.
+	if !t.Out().EqualWith(prev.Out()) {
+		return ErrInvalidCredits
+	}
+
+	return nil
+}
+
+// NewKeyRotationTransaction ... Generate key rotation from key pair of node to given key pair.
+// It continues previous transaction of node, or it's genesis transaction of new key if there is none.
+func (n *Node) NewKeyRotationTransaction(kp *KeyPair) (Transaction, error) {
+	timestamp := int(time.Now().Unix())
+	timestampByte := UInt64ToBytes(uint64(timestamp))
+	id := SHA256(JoinBytes(kp.Public, n.PublicKey(), timestampByte))
+
+	t := Transaction{
+		Header: TransactionHeader{
+			TransactionID:      id,
+			Timestamp:          timestamp,
+			PrevTransactionID:  id,
+			RequesterPublicKey: kp.Public,
+			RequesteePublicKey: n.PublicKey(),
+		},
+		Meta:   KeyRotationMeta,
+		Output: TXOutput{Accepted: 1, Rejected: 0},
+	}
+
+	if prev := n.PrevTransaction(); prev != nil {
+		t.Header.PrevTransactionID = prev.ID()
+		t.Output = prev.Out()
+	}
+
+	sig, err := kp.Sign(t.RequesterHash())
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	t.Header.RequesterSignature = sig
+
+	return n.SignTransaction(t), nil
+}
+
+// RotateKeyPair ... Rotate key pair of node to given one.
+// Key rotation is announced to routing table before key pair is replaced, since peers still authenticate old key,
+// then it continues transactions of node.
+func (n *Node) RotateKeyPair(kp *KeyPair) (Transaction, error) {
+	t, err := n.NewKeyRotationTransaction(kp)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	m := n.SignMessage(NewKeyRotationMessage(t))
+
+	mjson, err := m.MarshalJson()
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	n.Broadcast(mjson, func([]byte) error { return nil })
+
+	n.SetKeyPair(kp)
+
+	if !n.UpdatePrevTransaction(t) {
+		n.SetGenesisTransaction(t)
+	}
+
+	n.CheckAndAddTransactionToPool(t)
+
+	return t, nil
+}
+
+// AcceptKeyRotation ... Migrate node of old key in routing table to new key of key rotation.
+// Key rotation proves itself, so it's accepted from any sender.
+func (n *Node) AcceptKeyRotation(t Transaction) error {
+	if !t.IsKeyRotation() || !t.VerifyTransactionID() || !t.VerifyRequesterSig() || !t.VerifyRequesteeSig() {
+		return ErrInvalidKeyRotation
+	}
+
+	oldPK, newPK := t.RequesteePK(), t.RequesterPK()
+
+	n.RoutingTableLock.Lock()
+
+	rn := n.RoutingTable[Base58Encode(oldPK)]
+	if rn != nil {
+		migrated := *rn
+		migrated.PublicKey = newPK
+
+		delete(n.RoutingTable, Base58Encode(oldPK))
+		n.RoutingTable[Base58Encode(newPK)] = &migrated
+
+		n.saveRoutingTable()
+	}
+
+	n.RoutingTableLock.Unlock()
+
+	// Connection to it proved old key, the next one should prove new key.
+	if rn != nil {
+		n.Peers.Drop(rn.Address)
+	}
+
+	n.ClusterLock.Lock()
+	if bytes.Equal(n.ClusterHeadPK, oldPK) {
+		n.ClusterHeadPK = newPK
+	}
+	n.ClusterLock.Unlock()
+
+	return nil
+}
+
+// IsRotatedKey ... Test if key has been rotated in chain, i.e. it's retired.
+func (n *Node) IsRotatedKey(pk []byte) bool {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.isRotatedKey(pk)
+}
+
+// isRotatedKey ... Test if key has been rotated in chain.
+// NOTE: Caller should hold chain lock.
+func (n *Node) isRotatedKey(pk []byte) bool {
+	for _, t := range n.transactionsAt(n.Index.LocateTransactionsByRequestee(pk)) {
+		if t.IsKeyRotation() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkKeyRotation ... Check if key rotation continues transactions of old key, from chain or transactions pool.
+func (n *Node) checkKeyRotation(t Transaction) error {
+	n.ChainLock.RLock()
+	rotated := n.isRotatedKey(t.RequesteePK())
+	oldUsed := len(n.Index.LocateTransactionsByRequester(t.RequesteePK())) != 0
+	newUsed := len(n.Index.LocateTransactionsByRequester(t.RequesterPK())) != 0
+	n.ChainLock.RUnlock()
+
+	if rotated {
+		return ErrRotatedKey
+	}
+
+	// Identity is never merged into a key which has its own history.
+	if newUsed {
+		return ErrInvalidKeyRotation
+	}
+
+	if t.IsGenesisTransaction() {
+		if oldUsed {
+			return ErrInvalidKeyRotation
+		}
+
+		return VerifyKeyRotation(nil, t)
+	}
+
+	b, prev := n.PrevTransactionOf(t)
+	if !b {
+		return ErrUnknownPrevTransaction
+	}
+
+	err := n.checkFork(t)
+	if err != nil {
+		return err
+	}
+
+	return VerifyKeyRotation(&prev, t)
+}
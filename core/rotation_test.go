@@ -0,0 +1,212 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Test rotating key pair, chain of old key continues in new key.
+func TestKeyRotation(t *testing.T) {
+	generator, _ := NewNode("127.0.0.1", 0)
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	genesis := requester.NewGenesisTransaction([]byte("genesis"))
+	requester.SetGenesisTransaction(genesis)
+	generator.CheckAndAddTransactionToPool(genesis)
+
+	t1 := requestee.ConfirmTransaction(requester.NewPendingTransaction(requestee.PublicKey(), []byte("before")))
+	requester.UpdatePrevTransaction(t1)
+	generator.CheckAndAddTransactionToPool(t1)
+
+	if b, _ := generator.ProduceBlock(); !b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() testing failed"))
+	}
+
+	old, _ := NewNode("127.0.0.1", 0)
+	old.SetKeyPair(requester.KeyPair())
+	old.PreviousTransaction = &t1
+
+	kp, _ := NewECDSAKeyPair()
+
+	rotation, err := requester.RotateKeyPair(kp)
+	if err != nil {
+		panic(err)
+	}
+
+	if !bytes.Equal(requester.PublicKey(), kp.Public) || !bytes.Equal(requester.PrevTransaction().ID(), rotation.ID()) {
+		panic(fmt.Errorf("(*Node) RotateKeyPair() should replace key pair and continue previous transaction"))
+	}
+
+	if err := generator.CheckTransaction(rotation); err != nil {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should accept key rotation: %v", err))
+	}
+
+	generator.CheckAndAddTransactionToPool(rotation)
+
+	if b, _ := generator.ProduceBlock(); !b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should seal key rotation"))
+	}
+
+	// New key continues chain of old key.
+	t2 := requestee.ConfirmTransaction(requester.NewPendingTransaction(requestee.PublicKey(), []byte("after")))
+
+	if err := generator.CheckTransaction(t2); err != nil {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should accept transaction of new key: %v", err))
+	}
+
+	// Old key is retired.
+	forged := requestee.ConfirmTransaction(old.NewPendingTransaction(requestee.PublicKey(), []byte("forged")))
+
+	if err := generator.CheckTransaction(forged); err != ErrRotatedKey {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should refuse transaction of rotated key"))
+	}
+
+	another, _ := NewECDSAKeyPair()
+	again, _ := old.NewKeyRotationTransaction(another)
+
+	if err := generator.CheckTransaction(again); err != ErrRotatedKey {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should refuse rotating key twice"))
+	}
+
+	_, last := generator.GetLastBlock()
+	b, _ := generator.NewBlock(last.ID(), TransactionSlice{forged})
+
+	if err := ExtendChain(generator.Chain, b).Validate(); err == nil {
+		panic(fmt.Errorf("(Blockchain) Validate() should refuse transaction of rotated key"))
+	}
+
+	generator.CheckAndAddTransactionToPool(t2)
+	generator.ProduceBlock()
+
+	if err := generator.ValidateChain(); err != nil {
+		panic(err)
+	}
+
+	// Reputation moves to new key.
+	if r := generator.ReputationOf(kp.Public); r.Transactions != 4 || r.Accepted != 3 {
+		panic(fmt.Errorf("Reputation of rotated key should move to new key"))
+	}
+
+	if r := generator.ReputationOf(old.PublicKey()); r.Transactions != 0 {
+		panic(fmt.Errorf("Rotated key should have no reputation"))
+	}
+}
+
+// Test rotating key pair of node without transactions.
+func TestKeyRotationWithoutHistory(t *testing.T) {
+	generator, _ := NewNode("127.0.0.1", 0)
+	n, _ := NewNode("127.0.0.1", 0)
+
+	kp, _ := NewECDSAKeyPair()
+
+	rotation, err := n.RotateKeyPair(kp)
+	if err != nil {
+		panic(err)
+	}
+
+	if !rotation.IsGenesisTransaction() || n.PrevTransaction() == nil {
+		panic(fmt.Errorf("Key rotation without history should be genesis transaction of new key"))
+	}
+
+	if err := generator.CheckTransaction(rotation); err != nil {
+		panic(err)
+	}
+}
+
+// Test migrating routing table to new key.
+func TestAcceptKeyRotation(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	m, _ := NewNode("127.0.0.1", 0)
+
+	oldPK := m.PublicKey()
+
+	n.CheckAndAddNodeToRoutingTable(RemoteNode{PublicKey: oldPK, Address: "127.0.0.1:1"})
+	n.SetCluster("a", oldPK)
+
+	kp, _ := NewECDSAKeyPair()
+	rotation, _ := m.NewKeyRotationTransaction(kp)
+
+	tampered := rotation
+	tampered.Output.Accepted++
+
+	if err := n.AcceptKeyRotation(tampered); err != ErrInvalidKeyRotation {
+		panic(fmt.Errorf("(*Node) AcceptKeyRotation() should refuse tampered key rotation"))
+	}
+
+	if err := n.AcceptKeyRotation(rotation); err != nil {
+		panic(err)
+	}
+
+	if n.IsInRoutingTable(oldPK) || !n.IsInRoutingTable(kp.Public) {
+		panic(fmt.Errorf("(*Node) AcceptKeyRotation() should migrate routing table"))
+	}
+
+	if _, rn := n.GetNodeByPublicKey(kp.Public); rn.Address != "127.0.0.1:1" {
+		panic(fmt.Errorf("(*Node) AcceptKeyRotation() should keep address of node"))
+	}
+
+	if !bytes.Equal(n.ClusterHead(), kp.Public) {
+		panic(fmt.Errorf("(*Node) AcceptKeyRotation() should migrate cluster head"))
+	}
+}
+
+// Test key rotation and transaction of old key in pool claiming the same previous transaction.
+func TestKeyRotationFork(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	genesis := requester.NewGenesisTransaction([]byte("genesis"))
+	requester.SetGenesisTransaction(genesis)
+	n.CheckAndAddTransactionToPool(genesis)
+
+	kp, _ := NewECDSAKeyPair()
+	rotation, _ := requester.NewKeyRotationTransaction(kp)
+	n.CheckAndAddTransactionToPool(rotation)
+
+	t1 := requestee.ConfirmTransaction(requester.NewPendingTransaction(requestee.PublicKey(), []byte("t1")))
+	if err := n.CheckTransaction(t1); err != ErrForkedTransaction {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should reject transaction forking key rotation, got %v", err))
+	}
+
+	n.RemoveTransactionByIDFromPool(rotation.ID())
+
+	if err := n.CheckTransaction(t1); err != nil {
+		panic(err)
+	}
+
+	n.CheckAndAddTransactionToPool(t1)
+
+	if err := n.CheckTransaction(rotation); err != ErrForkedTransaction {
+		panic(fmt.Errorf("(*Node) CheckTransaction() should reject key rotation forking transaction, got %v", err))
+	}
+}
+
+// Test reading key pair while it's rotated.
+func TestKeyRotationConcurrentReads(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	n.SetGenesisTransaction(n.NewGenesisTransaction([]byte("genesis")))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			m := n.SignMessage(Message{Type: Ping})
+			if !m.VerifySignature() {
+				panic(fmt.Errorf("(*Node) SignMessage() signed with torn key pair"))
+			}
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		if err := n.UpdateKeyPair(); err != nil {
+			panic(err)
+		}
+	}
+
+	<-done
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+)
+
+// SignatureCacheSize ... Number of transactions whose verified signatures are remembered.
+const SignatureCacheSize = 8192
+
+// signatureCache ... Transactions whose signatures have been verified, keyed by transaction id.
+// Fingerprint of signed content is kept along, so transaction carrying the same id but other content
+// or signatures is verified again.
+type signatureCache struct {
+	lock    sync.Mutex
+	entries map[string][]byte
+	order   []string // Keys in insertion order, the oldest one is evicted first
+}
+
+// signatureFingerprint ... Get fingerprint of everything signed in transaction and its signatures.
+// Requestee hash covers id, keys, previous transaction, meta, requester signature and output.
+func signatureFingerprint(t Transaction) []byte {
+	return SHA256(JoinBytesWithLength(t.RequesteeHash(), t.RequesteeSig()))
+}
+
+// contains ... Test if signatures of transaction have been verified.
+func (c *signatureCache) contains(t Transaction) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	fp, ok := c.entries[Base58Encode(t.ID())]
+
+	return ok && bytes.Equal(fp, signatureFingerprint(t))
+}
+
+// add ... Record transaction whose signatures have been verified.
+func (c *signatureCache) add(t Transaction) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string][]byte)
+	}
+
+	key := Base58Encode(t.ID())
+	if _, ok := c.entries[key]; !ok {
+		if len(c.order) >= SignatureCacheSize {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+
+		c.order = append(c.order, key)
+	}
+
+	c.entries[key] = signatureFingerprint(t)
+}
+
+// VerifyTransactionSignatures ... Verify id and signatures of transaction, verified ones are cached.
+func (n *Node) VerifyTransactionSignatures(t Transaction) bool {
+	if n.sigCache.contains(t) {
+		return true
+	}
+
+	if !t.VerifyTransactionID() || !t.VerifyRequesterSig() || !t.VerifyRequesteeSig() {
+		return false
+	}
+
+	n.sigCache.add(t)
+
+	return true
+}
+
+// VerifyTransactionsSignatures ... Verify id and signatures of transactions in parallel,
+// it tells for each transaction if it's valid. Workers are bounded by number of CPUs.
+func (n *Node) VerifyTransactionsSignatures(trs []Transaction) []bool {
+	valid := make([]bool, len(trs))
+
+	workers := runtime.NumCPU()
+	if workers > len(trs) {
+		workers = len(trs)
+	}
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				valid[j] = n.VerifyTransactionSignatures(trs[j])
+			}
+		}()
+	}
+
+	for i := range trs {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	return valid
+}
+
+// CheckAndAddTransactionsToPool ... Check transactions and add valid ones into transactions pool.
+// Signatures are verified in parallel first, so checking them one by one afterwards costs no signature verification.
+func (n *Node) CheckAndAddTransactionsToPool(trs TransactionSlice) {
+	// Transaction could follow another one of the same batch.
+	trs = orderByPrevTransaction(trs)
+
+	valid := n.VerifyTransactionsSignatures(trs)
+
+	for i, t := range trs {
+		if valid[i] && n.CheckTransaction(t) == nil {
+			n.CheckAndAddTransactionToPool(t)
+		}
+	}
+}
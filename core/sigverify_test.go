@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test verifying signatures of transactions in parallel.
+func TestVerifyTransactionsSignatures(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+
+	var trs TransactionSlice
+	for i := 0; i < 20; i++ {
+		requester, _ := NewNode("127.0.0.1", 0)
+		trs = append(trs, requester.NewGenesisTransaction([]byte(fmt.Sprint(i))))
+	}
+
+	// Tampered transactions.
+	trs[3].Output.Accepted++
+	trs[7].Header.RequesterSignature = trs[8].Header.RequesterSignature
+
+	valid := n.VerifyTransactionsSignatures(trs)
+
+	for i, v := range valid {
+		if v != (i != 3 && i != 7) {
+			panic(fmt.Errorf("(*Node) VerifyTransactionsSignatures() testing failed at %d", i))
+		}
+	}
+
+	if !n.sigCache.contains(trs[0]) || n.sigCache.contains(trs[3]) {
+		panic(fmt.Errorf("Only valid transactions should be cached"))
+	}
+
+	// Transaction with cached id but other content is verified again.
+	tampered := trs[0]
+	tampered.Output.Rejected++
+
+	if n.VerifyTransactionSignatures(tampered) {
+		panic(fmt.Errorf("(*Node) VerifyTransactionSignatures() should refuse tampered transaction with cached id"))
+	}
+
+	if len(n.VerifyTransactionsSignatures(nil)) != 0 {
+		panic(fmt.Errorf("(*Node) VerifyTransactionsSignatures() should accept empty batch"))
+	}
+}
+
+// Test checking batch of transactions, which follow each other.
+func TestCheckAndAddTransactionsToPool(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	requester, _ := NewNode("127.0.0.1", 0)
+	requestee, _ := NewNode("127.0.0.1", 0)
+
+	genesis := requester.NewGenesisTransaction([]byte("genesis"))
+	requester.SetGenesisTransaction(genesis)
+
+	t1 := requestee.ConfirmTransaction(requester.NewPendingTransaction(requestee.PublicKey(), []byte("t1")))
+
+	forged := t1
+	forged.Output.Accepted++
+
+	n.CheckAndAddTransactionsToPool(TransactionSlice{forged, t1, genesis})
+
+	if !n.IsInTransactionsPool(genesis.ID()) || !n.IsInTransactionsPool(t1.ID()) {
+		panic(fmt.Errorf("(*Node) CheckAndAddTransactionsToPool() should add valid transactions"))
+	}
+
+	if _, pool := n.GetTransactionsOfPool(); len(pool) != 2 {
+		panic(fmt.Errorf("(*Node) CheckAndAddTransactionsToPool() should refuse forged transaction"))
+	}
+}
@@ -0,0 +1,650 @@
+package core
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store ... Persistent storage of node state.
+// Load functions return empty values (and nil error) if nothing has been saved yet.
+// Saving nil key pair removes saved one.
+type Store interface {
+	LoadKeyPair() (*KeyPair, error)
+	SaveKeyPair(kp *KeyPair) error
+	LoadBlocks() (BlockSlice, error)
+	AppendBlock(b Block) error
+	TruncateBlocks(height int) error
+	LoadHeaders() ([]SignedHeader, error)
+	AppendHeader(h SignedHeader) error
+	TruncateHeaders(height int) error
+	LoadTransactionsPool() (TransactionSlice, error)
+	SaveTransactionsPool(trs TransactionSlice) error
+	LoadPendingTransactions() (TransactionSlice, error)
+	SavePendingTransactions(trs TransactionSlice) error
+	LoadRoutingTable() ([]RemoteNode, error)
+	SaveRoutingTable(nodes []RemoteNode) error
+	LoadPrevTransaction() (*Transaction, error)
+	SavePrevTransaction(t *Transaction) error
+	Close() error
+}
+
+const (
+	blockLogFile            = "blocks.log"
+	blockIndexFile          = "blocks.idx"
+	headerLogFile           = "headers.log"
+	headerIndexFile         = "headers.idx"
+	keyPairFile             = "keypair.json"
+	transactionsPoolFile    = "pool.json"
+	pendingTransactionsFile = "pending.json"
+	routingTableFile        = "nodes.json"
+	prevTransactionFile     = "prev.json"
+
+	recordHeaderSize = 8            // | length ... 4 bytes | crc32 ... 4 bytes |
+	indexEntrySize   = 8            // | offset ... 8 bytes |
+	maxRecordSize    = MaxFrameSize // Record is a block or header, which is received in one frame
+)
+
+// FileStore ... File backed store.
+// Blocks, and headers of light node, are kept in append-only logs of records.
+// Other states are small snapshots, they're replaced atomically on every save.
+type FileStore struct {
+	lock    sync.Mutex
+	dir     string
+	blocks  *recordLog // Block Json records
+	headers *recordLog // Signed header Json records
+}
+
+// NewFileStore ... Open file store in given directory, create it if it doesn't exist.
+// A partially written record (e.g. by a crash) is discarded.
+func NewFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := openRecordLog(filepath.Join(dir, blockLogFile), filepath.Join(dir, blockIndexFile))
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := openRecordLog(filepath.Join(dir, headerLogFile), filepath.Join(dir, headerIndexFile))
+	if err != nil {
+		blocks.close()
+		return nil, err
+	}
+
+	return &FileStore{dir: dir, blocks: blocks, headers: headers}, nil
+}
+
+// recordLog ... Append-only log of records, every record is:
+// | length ... 4 bytes | crc32 ... 4 bytes | data ... length bytes |
+// and the index keeps offset of every record, so record could be read by height and log is not scanned on open.
+type recordLog struct {
+	log     *os.File
+	index   *os.File
+	offsets []int64 // Offset of each record
+	size    int64   // Size of valid records in log
+}
+
+// openRecordLog ... Open log and its index, create them if they don't exist.
+func openRecordLog(logPath, indexPath string) (*recordLog, error) {
+	log, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := os.OpenFile(indexPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+
+	rl := &recordLog{log: log, index: index}
+
+	err = rl.recover()
+	if err != nil {
+		rl.close()
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// recover ... Load index of log, and scan only records after the last indexed one.
+// Log is rescanned from start if index is inconsistent with it, and incomplete trailing record is dropped.
+func (rl *recordLog) recover() error {
+	indexed, err := rl.loadIndex()
+	if err != nil {
+		// Index could always be rebuilt from log.
+		indexed = nil
+	}
+
+	info, err := rl.log.Stat()
+	if err != nil {
+		return err
+	}
+
+	rl.offsets = append([]int64{}, indexed...)
+	rl.size = 0
+
+	if len(indexed) != 0 {
+		last := indexed[len(indexed)-1]
+		left := info.Size() - last
+
+		data, err := readRecord(io.NewSectionReader(rl.log, last, left), left)
+		if err != nil {
+			// The last indexed record is not in log, index is ahead of log.
+			indexed = nil
+			rl.offsets = nil
+		} else {
+			rl.size = last + int64(recordHeaderSize+len(data))
+		}
+	}
+
+	_, err = rl.log.Seek(rl.size, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	for {
+		data, err := readRecord(rl.log, info.Size()-rl.size)
+		if err != nil {
+			// Incomplete or corrupted record, we stop here.
+			break
+		}
+
+		rl.offsets = append(rl.offsets, rl.size)
+		rl.size += int64(recordHeaderSize + len(data))
+	}
+
+	err = rl.log.Truncate(rl.size)
+	if err != nil {
+		return err
+	}
+
+	if len(indexed) == len(rl.offsets) {
+		return nil
+	}
+
+	idx := make([]byte, 0, len(rl.offsets)*indexEntrySize)
+	for _, o := range rl.offsets {
+		idx = append(idx, UInt64ToBytes(uint64(o))...)
+	}
+
+	err = rl.index.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	_, err = rl.index.WriteAt(idx, 0)
+	if err != nil {
+		return err
+	}
+
+	return rl.index.Sync()
+}
+
+// loadIndex ... Load offsets of records from index, error if they could not be offsets of log.
+func (rl *recordLog) loadIndex() ([]int64, error) {
+	info, err := rl.index.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := make([]byte, info.Size())
+
+	_, err = rl.index.ReadAt(idx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(idx)%indexEntrySize != 0 {
+		return nil, errors.New("Truncated entry in index of log")
+	}
+
+	var offsets []int64
+
+	for i := 0; i < len(idx); i += indexEntrySize {
+		o := int64(binary.LittleEndian.Uint64(idx[i : i+indexEntrySize]))
+
+		if (len(offsets) == 0 && o != 0) || (len(offsets) != 0 && o <= offsets[len(offsets)-1]+recordHeaderSize) {
+			return nil, errors.New("Index of log is inconsistent")
+		}
+
+		offsets = append(offsets, o)
+	}
+
+	return offsets, nil
+}
+
+// readRecord ... Read one record from reader, which has given number of bytes left.
+// Length of record is checked before reading its data, so corrupted length never allocates more than a record.
+func readRecord(r io.Reader, left int64) ([]byte, error) {
+	header := make([]byte, recordHeaderSize)
+
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[:4])
+	sum := binary.LittleEndian.Uint32(header[4:])
+
+	if length > maxRecordSize || int64(recordHeaderSize)+int64(length) > left {
+		return nil, errors.New("Length of record exceeds log")
+	}
+
+	data := make([]byte, length)
+
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if crc32.ChecksumIEEE(data) != sum {
+		return nil, errors.New("Checksum mismatch of record")
+	}
+
+	return data, nil
+}
+
+// height ... Get number of records.
+func (rl *recordLog) height() int {
+	return len(rl.offsets)
+}
+
+// read ... Read record at given height.
+func (rl *recordLog) read(height int) ([]byte, error) {
+	if height < 0 || height >= len(rl.offsets) {
+		return nil, fmt.Errorf("Record #%d does not exist", height)
+	}
+
+	left := rl.size - rl.offsets[height]
+
+	return readRecord(io.NewSectionReader(rl.log, rl.offsets[height], left), left)
+}
+
+// append ... Append record to log, the record is durable once it returns.
+func (rl *recordLog) append(data []byte) error {
+	if len(data) > maxRecordSize {
+		return fmt.Errorf("Record of %d bytes exceeds max record size", len(data))
+	}
+
+	record := JoinBytes(UInt32ToBytes(uint32(len(data))), UInt32ToBytes(crc32.ChecksumIEEE(data)), data)
+
+	_, err := rl.log.WriteAt(record, rl.size)
+	if err != nil {
+		return err
+	}
+
+	err = rl.log.Sync()
+	if err != nil {
+		return err
+	}
+
+	// Index could always be rebuilt from log, so log is synced first.
+	_, err = rl.index.WriteAt(UInt64ToBytes(uint64(rl.size)), int64(len(rl.offsets)*indexEntrySize))
+	if err != nil {
+		return err
+	}
+
+	err = rl.index.Sync()
+	if err != nil {
+		return err
+	}
+
+	rl.offsets = append(rl.offsets, rl.size)
+	rl.size += int64(len(record))
+
+	return nil
+}
+
+// truncate ... Drop records from given height on.
+func (rl *recordLog) truncate(height int) error {
+	if height < 0 || height > len(rl.offsets) {
+		return fmt.Errorf("Record height %d out of range", height)
+	}
+
+	if height == len(rl.offsets) {
+		return nil
+	}
+
+	size := rl.offsets[height]
+
+	// Index could always be rebuilt from log, so log is truncated first.
+	err := rl.log.Truncate(size)
+	if err != nil {
+		return err
+	}
+
+	err = rl.log.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = rl.index.Truncate(int64(height * indexEntrySize))
+	if err != nil {
+		return err
+	}
+
+	err = rl.index.Sync()
+	if err != nil {
+		return err
+	}
+
+	rl.offsets = rl.offsets[:height]
+	rl.size = size
+
+	return nil
+}
+
+// close ... Close log and its index.
+func (rl *recordLog) close() error {
+	err := rl.log.Close()
+
+	if e := rl.index.Close(); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// Height ... Get number of stored blocks.
+func (s *FileStore) Height() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.blocks.height()
+}
+
+// ReadBlock ... Read block at given height.
+func (s *FileStore) ReadBlock(height int) (Block, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data, err := s.blocks.read(height)
+	if err != nil {
+		return Block{}, err
+	}
+
+	var b Block
+
+	err = b.UnmarshalJson(data)
+	if err != nil {
+		return Block{}, err
+	}
+
+	return b, nil
+}
+
+// LoadBlocks ... Load all blocks.
+func (s *FileStore) LoadBlocks() (BlockSlice, error) {
+	var bs BlockSlice
+
+	for i := 0; i < s.Height(); i++ {
+		b, err := s.ReadBlock(i)
+		if err != nil {
+			return nil, err
+		}
+
+		bs = append(bs, b)
+	}
+
+	return bs, nil
+}
+
+// AppendBlock ... Append block to log, the block is durable once it returns.
+func (s *FileStore) AppendBlock(b Block) error {
+	data, err := b.MarshalJson()
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.blocks.append(data)
+}
+
+// TruncateBlocks ... Drop blocks from given height on, so chain could be reorganized.
+func (s *FileStore) TruncateBlocks(height int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.blocks.truncate(height)
+}
+
+// LoadHeaders ... Load all headers of light node.
+func (s *FileStore) LoadHeaders() ([]SignedHeader, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var headers []SignedHeader
+
+	for i := 0; i < s.headers.height(); i++ {
+		data, err := s.headers.read(i)
+		if err != nil {
+			return nil, err
+		}
+
+		var h SignedHeader
+
+		err = json.Unmarshal(data, &h)
+		if err != nil {
+			return nil, err
+		}
+
+		headers = append(headers, h)
+	}
+
+	return headers, nil
+}
+
+// AppendHeader ... Append header to log, the header is durable once it returns.
+func (s *FileStore) AppendHeader(h SignedHeader) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.headers.append(data)
+}
+
+// TruncateHeaders ... Drop headers from given height on, so header chain could be reorganized.
+func (s *FileStore) TruncateHeaders(height int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.headers.truncate(height)
+}
+
+// writeFileAtomic ... Replace file with data, either old or new content survives a crash.
+// File is readable by owner only, since it could hold private key.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(0600)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return err
+	}
+
+	// Make rename durable.
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+// saveJSON ... Save value into file as Json.
+func (s *FileStore) saveJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return writeFileAtomic(filepath.Join(s.dir, name), data)
+}
+
+// remove ... Remove file, it's fine if file doesn't exist.
+func (s *FileStore) remove(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := os.Remove(filepath.Join(s.dir, name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// loadJSON ... Load value from Json file, return false if file doesn't exist.
+func (s *FileStore) loadJSON(name string, v interface{}) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(data, v)
+}
+
+// LoadKeyPair ... Load key pair.
+func (s *FileStore) LoadKeyPair() (*KeyPair, error) {
+	var kp KeyPair
+
+	b, err := s.loadJSON(keyPairFile, &kp)
+	if !b || err != nil {
+		return nil, err
+	}
+
+	return &kp, nil
+}
+
+// SaveKeyPair ... Save key pair.
+func (s *FileStore) SaveKeyPair(kp *KeyPair) error {
+	if kp == nil {
+		return s.remove(keyPairFile)
+	}
+
+	return s.saveJSON(keyPairFile, kp)
+}
+
+// LoadTransactionsPool ... Load transactions pool.
+func (s *FileStore) LoadTransactionsPool() (TransactionSlice, error) {
+	var trs TransactionSlice
+
+	_, err := s.loadJSON(transactionsPoolFile, &trs)
+
+	return trs, err
+}
+
+// SaveTransactionsPool ... Save transactions pool.
+func (s *FileStore) SaveTransactionsPool(trs TransactionSlice) error {
+	return s.saveJSON(transactionsPoolFile, trs)
+}
+
+// LoadPendingTransactions ... Load pending transactions.
+func (s *FileStore) LoadPendingTransactions() (TransactionSlice, error) {
+	var trs TransactionSlice
+
+	_, err := s.loadJSON(pendingTransactionsFile, &trs)
+
+	return trs, err
+}
+
+// SavePendingTransactions ... Save pending transactions.
+func (s *FileStore) SavePendingTransactions(trs TransactionSlice) error {
+	return s.saveJSON(pendingTransactionsFile, trs)
+}
+
+// LoadRoutingTable ... Load routing table.
+func (s *FileStore) LoadRoutingTable() ([]RemoteNode, error) {
+	var nodes []RemoteNode
+
+	_, err := s.loadJSON(routingTableFile, &nodes)
+
+	return nodes, err
+}
+
+// SaveRoutingTable ... Save routing table.
+func (s *FileStore) SaveRoutingTable(nodes []RemoteNode) error {
+	return s.saveJSON(routingTableFile, nodes)
+}
+
+// LoadPrevTransaction ... Load previous transaction.
+func (s *FileStore) LoadPrevTransaction() (*Transaction, error) {
+	var t *Transaction
+
+	_, err := s.loadJSON(prevTransactionFile, &t)
+
+	return t, err
+}
+
+// SavePrevTransaction ... Save previous transaction.
+func (s *FileStore) SavePrevTransaction(t *Transaction) error {
+	return s.saveJSON(prevTransactionFile, t)
+}
+
+// Close ... Close store.
+func (s *FileStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.blocks.close()
+
+	if e := s.headers.close(); err == nil {
+		err = e
+	}
+
+	return err
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test restoring node from file store.
+func TestNodeWithFileStore(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	n, err := NewNodeWithStore("127.0.0.1", 0, s)
+	if err != nil {
+		panic(err)
+	}
+
+	m, _ := NewNode("127.0.0.1", 0)
+
+	genesis := n.NewGenesisTransaction([]byte("genesis"))
+	n.SetGenesisTransaction(genesis)
+	n.CheckAndAddTransactionToPool(genesis)
+	n.ProduceBlock()
+
+	n.CheckAndAddTransactionToPool(m.NewGenesisTransaction([]byte("genesis")))
+	n.CheckAndAddPendingTransaction(GenRandomTransaction())
+	n.CheckAndAddNodeToRoutingTable(GenRandomRemoteNode())
+
+	s.Close()
+
+	// Restart node.
+	s, err = NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	r, err := NewNodeWithStore("127.0.0.1", 0, s)
+	if err != nil {
+		panic(err)
+	}
+
+	if !bytes.Equal(n.PublicKey(), r.PublicKey()) {
+		panic(fmt.Errorf("NewNodeWithStore() should restore key pair"))
+	}
+
+	if !n.Chain.Blocks.EqualWith(r.Chain.Blocks) || r.Chain.Height() != 1 {
+		panic(fmt.Errorf("NewNodeWithStore() should restore chain"))
+	}
+
+	if c, _ := r.GetTransactionsOfPool(); c != 1 {
+		panic(fmt.Errorf("NewNodeWithStore() should restore transactions pool"))
+	}
+
+	if c, _ := r.GetPendingTransactions(); c != 1 {
+		panic(fmt.Errorf("NewNodeWithStore() should restore pending transactions"))
+	}
+
+	if c, _ := r.GetNodesOfRoutingTable(); c != 1 {
+		panic(fmt.Errorf("NewNodeWithStore() should restore routing table"))
+	}
+
+	if r.PrevTransaction() == nil || !r.PrevTransaction().EqualWith(genesis) {
+		panic(fmt.Errorf("NewNodeWithStore() should restore previous transaction"))
+	}
+}
+
+// Test discarding partially written block record.
+func TestFileStoreRecover(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	bs := GenRandomBlockSlice(3, 3)
+
+	for _, b := range bs[:2] {
+		if err := s.AppendBlock(b); err != nil {
+			panic(err)
+		}
+	}
+
+	s.Close()
+
+	// Simulate crash in the middle of writing record.
+	f, _ := os.OpenFile(filepath.Join(dir, blockLogFile), os.O_WRONLY|os.O_APPEND, 0600)
+	f.Write([]byte{0xff, 0x00, 0x00, 0x00, 0x01})
+	f.Close()
+
+	s, err = NewFileStore(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	if s.Height() != 2 {
+		panic(fmt.Errorf("NewFileStore() should discard incomplete record"))
+	}
+
+	if err := s.AppendBlock(bs[2]); err != nil {
+		panic(err)
+	}
+
+	loaded, err := s.LoadBlocks()
+	if err != nil || !loaded.EqualWith(bs) || len(loaded) != 3 {
+		panic(fmt.Errorf("(*FileStore) LoadBlocks() testing failed"))
+	}
+
+	s.Close()
+
+	// Index behind log, e.g. crash after syncing log, is completed from log.
+	indexPath := filepath.Join(dir, blockIndexFile)
+	os.Truncate(indexPath, indexEntrySize)
+
+	reopen := func() {
+		s, err = NewFileStore(dir)
+		if err != nil {
+			panic(err)
+		}
+
+		loaded, err := s.LoadBlocks()
+		if err != nil || !loaded.EqualWith(bs) || len(loaded) != 3 {
+			panic(fmt.Errorf("NewFileStore() should recover blocks from log"))
+		}
+
+		if info, _ := os.Stat(indexPath); info.Size() != 3*indexEntrySize {
+			panic(fmt.Errorf("NewFileStore() should rebuild block index"))
+		}
+
+		s.Close()
+	}
+
+	reopen()
+
+	// Inconsistent index is rebuilt from log.
+	os.WriteFile(indexPath, []byte{0x01, 0x02, 0x03}, 0600)
+	reopen()
+
+	os.WriteFile(indexPath, JoinBytes(UInt64ToBytes(0), UInt64ToBytes(1<<40)), 0600)
+	reopen()
+
+	// Corrupted length of trailing record is a torn tail, it's never allocated.
+	f, _ = os.OpenFile(filepath.Join(dir, blockLogFile), os.O_WRONLY|os.O_APPEND, 0600)
+	f.Write(JoinBytes(UInt32ToBytes(0xfffffff0), UInt32ToBytes(0), []byte("torn")))
+	f.Close()
+	reopen()
+
+	huge := JoinBytes(UInt32ToBytes(maxRecordSize+1), UInt32ToBytes(0))
+	if _, err := readRecord(bytes.NewReader(huge), 1<<40); err == nil {
+		panic(fmt.Errorf("readRecord() should reject record beyond max record size"))
+	}
+}
+
+// countingStore ... Store counting saves of snapshots.
+type countingStore struct {
+	Store
+	poolSaves    int
+	routingSaves int
+}
+
+// SaveTransactionsPool ... Count and save transactions pool.
+func (s *countingStore) SaveTransactionsPool(trs TransactionSlice) error {
+	s.poolSaves++
+	return s.Store.SaveTransactionsPool(trs)
+}
+
+// SaveRoutingTable ... Count and save routing table.
+func (s *countingStore) SaveRoutingTable(nodes []RemoteNode) error {
+	s.routingSaves++
+	return s.Store.SaveRoutingTable(nodes)
+}
+
+// Test snapshots are saved once per operation, not once per mutation.
+func TestNodeStoreBatching(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	if err != nil {
+		panic(err)
+	}
+	defer fs.Close()
+
+	s := &countingStore{Store: fs}
+
+	n, err := NewNodeWithStore("127.0.0.1", 0, s)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		m, _ := NewNode("127.0.0.1", 0)
+		n.CheckAndAddTransactionToPool(m.NewGenesisTransaction([]byte("genesis")))
+	}
+
+	s.poolSaves = 0
+
+	if b, _ := n.ProduceBlock(); !b {
+		panic(fmt.Errorf("(*Node) ProduceBlock() testing failed"))
+	}
+
+	if c, _ := n.GetTransactionsOfPool(); c != 0 || s.poolSaves != 1 {
+		panic(fmt.Errorf("(*Node) ProduceBlock() should save transactions pool once, saved %d times", s.poolSaves))
+	}
+
+	rn := GenRandomRemoteNode()
+	n.CheckAndAddNodeToRoutingTable(rn)
+
+	// Lastseen alone is not worth saving again so soon.
+	rn.Lastseen++
+	n.UpdateNodeForGivenPublicKey(rn.PublicKey, rn)
+
+	if s.routingSaves != 1 {
+		panic(fmt.Errorf("(*Node) UpdateNodeForGivenPublicKey() should not save lastseen at once"))
+	}
+
+	rn.Address = "localhost:3001"
+	n.UpdateNodeForGivenPublicKey(rn.PublicKey, rn)
+
+	if s.routingSaves != 2 {
+		panic(fmt.Errorf("(*Node) UpdateNodeForGivenPublicKey() should save changed address"))
+	}
+}
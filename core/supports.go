@@ -1,6 +1,7 @@
 package core
 
 import (
+	crand "crypto/rand"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
@@ -80,6 +81,40 @@ func JoinBytes(bs ...[]byte) []byte {
 	return data
 }
 
+// JoinBytesWithLength ... Concat bytes, each one is prefixed by its length.
+// It's used to encode fields unambiguously, e.g. ("ab", "c") and ("a", "bc") differ.
+func JoinBytesWithLength(bs ...[]byte) []byte {
+	var data []byte
+	for _, b := range bs {
+		data = append(data, UInt32ToBytes(uint32(len(b)))...)
+		data = append(data, b...)
+	}
+	return data
+}
+
+// SplitBytesWithLength ... Split bytes joined by JoinBytesWithLength.
+func SplitBytesWithLength(data []byte) ([][]byte, error) {
+	var bs [][]byte
+
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("Truncated length prefix")
+		}
+
+		l, _ := BytesToUInt32(data[:4])
+		data = data[4:]
+
+		if uint64(l) > uint64(len(data)) {
+			return nil, errors.New("Truncated bytes")
+		}
+
+		bs = append(bs, data[:l])
+		data = data[l:]
+	}
+
+	return bs, nil
+}
+
 // FitBytesIntoSpecificWidth ... Fit bytes into specific width.
 func FitBytesIntoSpecificWidth(data []byte, i int) []byte {
 	if len(data) < i {
@@ -101,13 +136,25 @@ func StripBytes(data []byte, b byte) []byte {
 	return nil
 }
 
-// GenRandomBytes ... Generate random bytes.
+// GenRandomBytes ... Generate random bytes, they're not for security use.
 func GenRandomBytes(l int) []byte {
 	p := make([]byte, l)
 	_, _ = rand.Read(p)
 	return p
 }
 
+// GenSecureRandomBytes ... Generate cryptographically secure random bytes, e.g. for nonces.
+func GenSecureRandomBytes(l int) ([]byte, error) {
+	p := make([]byte, l)
+
+	_, err := crand.Read(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
 // Distance ... Distance of two uint64.
 func Distance(a uint64, b uint64) uint64 {
 	if a >= b {
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxHeadersPerRequest ... Maximum number of block headers in reply to get headers message.
+	MaxHeadersPerRequest = 256
+	// MaxBlocksPerRequest ... Maximum number of blocks in reply to get blocks message.
+	MaxBlocksPerRequest = 32
+)
+
+// ErrInvalidSyncReply ... Peer replies with headers or blocks that don't match request.
+var ErrInvalidSyncReply = errors.New("Invalid reply of sync request")
+
+// GetHeaders ... Get block headers of main chain, from given height on.
+func (n *Node) GetHeaders(from, count int) []SignedHeader {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	if count > MaxHeadersPerRequest {
+		count = MaxHeadersPerRequest
+	}
+
+	var headers []SignedHeader
+
+	for i := from; i >= 0 && i < n.Chain.Height() && len(headers) < count; i++ {
+		headers = append(headers, n.Chain.Blocks[i].SignedHeader())
+	}
+
+	return headers
+}
+
+// GetBlocksByID ... Get blocks with given ids from block tree, unknown blocks are skipped.
+func (n *Node) GetBlocksByID(ids [][]byte) BlockSlice {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	var bs BlockSlice
+
+	for _, id := range ids {
+		if len(bs) == MaxBlocksPerRequest {
+			break
+		}
+
+		if b, block := n.Tree.Get(id); b {
+			bs = append(bs, block)
+		}
+	}
+
+	return bs
+}
+
+// NewAnnounceTipMessage ... Generate new message announcing tip of chain of node.
+func (n *Node) NewAnnounceTipMessage() Message {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	var tipID []byte
+	if b, last := n.Chain.LastBlock(); b {
+		tipID = last.ID()
+	}
+
+	return NewAnnounceTipMessage(n.PublicKey(), n.Addr(), n.Chain.Height(), tipID)
+}
+
+// AnnounceTip ... Announce tip of chain to cluster heads, which keep ledger.
+func (n *Node) AnnounceTip() {
+	m := n.SignMessage(n.NewAnnounceTipMessage())
+
+	mjson, err := m.MarshalJson()
+	if err != nil {
+		return
+	}
+
+	_, heads := n.GetClusterHeads()
+
+	n.Multicast(heads, mjson, func([]byte) error { return nil })
+}
+
+// HasBlock ... Test if block with given id is in block tree, or in header chain of light node.
+func (n *Node) HasBlock(id []byte) bool {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	if n.Light {
+		found, _ := n.Headers.GetHeaderByID(id)
+		return found
+	}
+
+	return n.Tree.Has(id)
+}
+
+// requestHeaders ... Request block headers of chain of node at given address.
+func (n *Node) requestHeaders(address string, from, count int) ([]SignedHeader, error) {
+	m := n.SignMessage(NewGetHeadersMessage(from, count))
+
+	mjson, err := m.MarshalJson()
+	if err != nil {
+		return nil, err
+	}
+
+	var reply HeadersData
+
+	err = n.Send(address, mjson, func(data []byte) error {
+		return reply.UnmarshalJson(data)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reply.Headers) > count {
+		return nil, ErrInvalidSyncReply
+	}
+
+	// Headers should link up, and be signed by their generators.
+	for i, h := range reply.Headers {
+		if i > 0 && !bytes.Equal(h.Header.PrevBlockID, reply.Headers[i-1].ID()) {
+			return nil, ErrInvalidSyncReply
+		}
+
+		if !h.VerifySignature() {
+			return nil, ErrInvalidSyncReply
+		}
+	}
+
+	return reply.Headers, nil
+}
+
+// requestBlocks ... Request blocks with given ids from node at given address, in the same order.
+func (n *Node) requestBlocks(address string, ids [][]byte) (BlockSlice, error) {
+	m := n.SignMessage(NewGetBlocksMessage(ids))
+
+	mjson, err := m.MarshalJson()
+	if err != nil {
+		return nil, err
+	}
+
+	var reply BlocksData
+
+	err = n.Send(address, mjson, func(data []byte) error {
+		return reply.UnmarshalJson(data)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reply.Blocks) != len(ids) {
+		return nil, ErrInvalidSyncReply
+	}
+
+	for i, b := range reply.Blocks {
+		if !bytes.Equal(b.ID(), ids[i]) {
+			return nil, ErrInvalidSyncReply
+		}
+	}
+
+	return reply.Blocks, nil
+}
+
+// SyncChain ... Catch up with chain of node at given address, and return number of blocks added.
+// Headers are fetched from our height on, going back further if they fork below it,
+// and every unknown block is fetched and validated before it's added into block tree.
+// Light node syncs headers only.
+func (n *Node) SyncChain(address string) (int, error) {
+	if n.Light {
+		return n.SyncHeaders(address)
+	}
+
+	n.ChainLock.RLock()
+	from := n.Chain.Height()
+	n.ChainLock.RUnlock()
+
+	added := 0
+	back := 1
+
+	for {
+		headers, err := n.requestHeaders(address, from, MaxHeadersPerRequest)
+		if err != nil {
+			return added, err
+		}
+
+		if len(headers) == 0 {
+			if from == 0 {
+				return added, nil
+			}
+
+			// Peer's chain is shorter than ours, look for fork below.
+			from = maxInt(from-back, 0)
+			back *= 2
+			continue
+		}
+
+		first := headers[0]
+
+		if from == 0 && len(first.Header.PrevBlockID) != 0 {
+			return added, ErrInvalidSyncReply
+		}
+
+		if from != 0 && !n.HasBlock(first.Header.PrevBlockID) {
+			// Peer's chain forks below, go back further.
+			from = maxInt(from-back, 0)
+			back *= 2
+			continue
+		}
+
+		var ids [][]byte
+		for _, h := range headers {
+			if !n.HasBlock(h.ID()) {
+				ids = append(ids, h.ID())
+			}
+		}
+
+		for len(ids) > 0 {
+			chunk := ids
+			if len(chunk) > MaxBlocksPerRequest {
+				chunk = chunk[:MaxBlocksPerRequest]
+			}
+			ids = ids[len(chunk):]
+
+			bs, err := n.requestBlocks(address, chunk)
+			if err != nil {
+				return added, err
+			}
+
+			for _, b := range bs {
+				_, err = n.AddBlock(b)
+				if err != nil {
+					return added, fmt.Errorf("Block %s from %s: %v", Base58Encode(b.ID()), address, err)
+				}
+
+				added++
+			}
+		}
+
+		if len(headers) < MaxHeadersPerRequest {
+			return added, nil
+		}
+
+		from += len(headers)
+	}
+}
+
+// maxInt ... Get the larger one of two integers.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// minInt ... Get the smaller one of two ints.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
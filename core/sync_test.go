@@ -0,0 +1,91 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+)
+
+// Run node serving chain to syncing nodes and light nodes.
+func RunSyncNode(n *Node) string {
+	if err := n.Run(); err != nil {
+		panic(err)
+	}
+
+	go func() {
+		for im := range n.MessageChannel {
+			switch im.Content.Type {
+			case GetHeaders:
+				var gh GetHeadersData
+				if gh.UnmarshalJson(im.Content.Data) == nil {
+					hjson, _ := HeadersData{Headers: n.GetHeaders(gh.From, gh.Count)}.MarshalJson()
+					im.Reply(hjson)
+				}
+			case GetBlocks:
+				var gb GetBlocksData
+				if gb.UnmarshalJson(im.Content.Data) == nil {
+					bjson, _ := BlocksData{Blocks: n.GetBlocksByID(gb.IDs)}.MarshalJson()
+					im.Reply(bjson)
+				}
+			case GetProof:
+				var gp GetProofData
+				if gp.UnmarshalJson(im.Content.Data) == nil {
+					pjson, _ := n.GetProof(gp.TransactionID).MarshalJson()
+					im.Reply(pjson)
+				}
+			}
+
+			im.Close()
+		}
+	}()
+
+	return n.Listerner.Addr().(*net.TCPAddr).String()
+}
+
+// Test syncing chain from other node.
+func TestSyncChain(t *testing.T) {
+	src, _ := GenValidChain(MaxHeadersPerRequest+4, 1)
+	addr := RunSyncNode(src)
+	defer src.Listerner.Close()
+
+	dst, _ := NewNode("127.0.0.1", 0)
+	defer dst.Peers.Close()
+
+	added, err := dst.SyncChain(addr)
+	if err != nil {
+		panic(err)
+	}
+
+	if added != src.Chain.Height() || !bytes.Equal(dst.tipID(), src.tipID()) {
+		panic(fmt.Errorf("(*Node) SyncChain() testing failed, %d blocks added", added))
+	}
+
+	if err := dst.ValidateChain(); err != nil {
+		panic(err)
+	}
+
+	// Nothing to sync.
+	if added, err := dst.SyncChain(addr); added != 0 || err != nil {
+		panic(fmt.Errorf("(*Node) SyncChain() should add nothing when synced: %v", err))
+	}
+
+	// dst forks away, and catches up with longer chain of src.
+	for i := 0; i < 2; i++ {
+		x, _ := NewNode("127.0.0.1", 0)
+		src.CheckAndAddTransactionToPool(x.NewGenesisTransaction([]byte("genesis")))
+		src.ProduceBlock()
+	}
+
+	x, _ := NewNode("127.0.0.1", 0)
+	dst.CheckAndAddTransactionToPool(x.NewGenesisTransaction([]byte("genesis")))
+	dst.ProduceBlock()
+
+	if added, err := dst.SyncChain(addr); added != 2 || err != nil {
+		panic(fmt.Errorf("(*Node) SyncChain() should follow fork: %d blocks added, %v", added, err))
+	}
+
+	if !bytes.Equal(dst.tipID(), src.tipID()) {
+		panic(fmt.Errorf("(*Node) SyncChain() should switch to longer chain"))
+	}
+}
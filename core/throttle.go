@@ -0,0 +1,241 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrGeneratorThrottled ... Generator has used up its block budget of current window.
+var ErrGeneratorThrottled = errors.New("Generator exceeds its block budget")
+
+// ThrottleConfig ... Rate limit of block generators, at most MaxBlocks blocks in any sliding window.
+// It limits the damage a compromised generator could do. Zero value imposes no limit.
+type ThrottleConfig struct {
+	Window    time.Duration // Length of sliding window
+	MaxBlocks int           // Maximum number of blocks of one generator in window
+}
+
+// enabled ... Test if throttling is enabled.
+func (c ThrottleConfig) enabled() bool {
+	return c.Window > 0 && c.MaxBlocks > 0
+}
+
+// window ... Length of sliding window in seconds, as block timestamps are.
+func (c ThrottleConfig) window() int {
+	return int(c.Window / time.Second)
+}
+
+// GeneratorBudget ... Block budget of generator in current window.
+type GeneratorBudget struct {
+	GeneratorID []byte // Public key of generator
+	Used        int    // Number of blocks in current window
+	Remaining   int    // Number of blocks generator could still produce in current window, -1 if it's not throttled
+	ResetAt     int    // The unix time that the oldest block leaves window, zero if no block is in window
+}
+
+// GeneratorBudgetJSONImpl ...
+type GeneratorBudgetJSONImpl struct {
+	GeneratorID string `json:"generator_id"`
+	Used        int    `json:"used"`
+	Remaining   int    `json:"remaining"`
+	ResetAt     int    `json:"reset_at"`
+}
+
+// MarshalJSON ... Serialize GeneratorBudget into Json.
+func (gb GeneratorBudget) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&GeneratorBudgetJSONImpl{
+		GeneratorID: Base58Encode(gb.GeneratorID),
+		Used:        gb.Used,
+		Remaining:   gb.Remaining,
+		ResetAt:     gb.ResetAt,
+	})
+}
+
+// UnmarshalJSON ... Read GeneratorBudget from Json.
+func (gb *GeneratorBudget) UnmarshalJSON(data []byte) error {
+	var g GeneratorBudgetJSONImpl
+
+	err := json.Unmarshal(data, &g)
+	if err != nil {
+		return err
+	}
+
+	gb.GeneratorID = Base58Decode(g.GeneratorID)
+	gb.Used = g.Used
+	gb.Remaining = g.Remaining
+	gb.ResetAt = g.ResetAt
+
+	return nil
+}
+
+// BlocksOfGeneratorSince ... Get timestamps of blocks produced by generator later than given unix time, the oldest first.
+func (bc Blockchain) BlocksOfGeneratorSince(generatorID []byte, since int) []int {
+	var timestamps []int
+
+	for _, b := range bc.Blocks {
+		if b.Header.Timestamp > since && bytes.Equal(b.Header.GeneratorID, generatorID) {
+			timestamps = append(timestamps, b.Header.Timestamp)
+		}
+	}
+
+	return timestamps
+}
+
+// budget ... Get block budget of generator from timestamps of its blocks in window, the oldest first.
+func (c ThrottleConfig) budget(generatorID []byte, timestamps []int) GeneratorBudget {
+	if !c.enabled() {
+		return GeneratorBudget{GeneratorID: generatorID, Remaining: -1}
+	}
+
+	gb := GeneratorBudget{
+		GeneratorID: generatorID,
+		Used:        len(timestamps),
+		Remaining:   c.MaxBlocks - len(timestamps),
+	}
+
+	if gb.Remaining < 0 {
+		gb.Remaining = 0
+	}
+
+	if len(timestamps) > 0 {
+		gb.ResetAt = timestamps[0] + c.window()
+	}
+
+	return gb
+}
+
+// BudgetOf ... Get block budget of generator in window ending at given unix time.
+func (bc Blockchain) BudgetOf(generatorID []byte, now int, c ThrottleConfig) GeneratorBudget {
+	var timestamps []int
+	if c.enabled() {
+		timestamps = bc.BlocksOfGeneratorSince(generatorID, now-c.window())
+	}
+
+	return c.budget(generatorID, timestamps)
+}
+
+// CheckBlockThrottle ... Check if generator of block has budget left at timestamp of block.
+func (bc Blockchain) CheckBlockThrottle(b Block, c ThrottleConfig) error {
+	if !c.enabled() {
+		return nil
+	}
+
+	if bc.BudgetOf(b.Header.GeneratorID, b.Header.Timestamp, c).Remaining == 0 {
+		return ErrGeneratorThrottled
+	}
+
+	return nil
+}
+
+// ValidateThrottle ... Check if no generator exceeds its block budget over history of chain.
+func (bc Blockchain) ValidateThrottle(c ThrottleConfig) error {
+	if !c.enabled() {
+		return nil
+	}
+
+	windowOf := make(map[string][]int) // Timestamps of blocks of generator in sliding window
+
+	for i, b := range bc.Blocks {
+		generator := Base58Encode(b.Header.GeneratorID)
+
+		// Slide window to end at this block.
+		window := windowOf[generator]
+		for len(window) > 0 && window[0] <= b.Header.Timestamp-c.window() {
+			window = window[1:]
+		}
+
+		if len(window) >= c.MaxBlocks {
+			return &ValidationError{BlockIndex: i, BlockID: b.ID(), Reason: "generator exceeds its block budget"}
+		}
+
+		windowOf[generator] = append(window, b.Header.Timestamp)
+	}
+
+	return nil
+}
+
+// blocksInWindow ... Get timestamps of blocks of main chain later than given unix time by generator, the oldest first.
+// Blocks are looked up through time index, so only blocks in window are visited.
+// NOTE: Caller should hold chain lock.
+func (n *Node) blocksInWindow(since int) map[string][]int {
+	timestampsOf := make(map[string][]int)
+
+	for _, height := range n.Index.LocateBlocksByTime(since+1, math.MaxInt) {
+		b := n.Chain.Blocks[height]
+		generator := Base58Encode(b.Header.GeneratorID)
+		timestampsOf[generator] = append(timestampsOf[generator], b.Header.Timestamp)
+	}
+
+	return timestampsOf
+}
+
+// budgetOf ... Get block budget of generator in main chain, in window ending at given unix time.
+// NOTE: Caller should hold chain lock.
+func (n *Node) budgetOf(generatorID []byte, now int) GeneratorBudget {
+	var timestamps []int
+	if n.Throttle.enabled() {
+		timestamps = n.blocksInWindow(now - n.Throttle.window())[Base58Encode(generatorID)]
+	}
+
+	return n.Throttle.budget(generatorID, timestamps)
+}
+
+// checkBlockThrottle ... Check if generator of block has budget left on top of main chain.
+// NOTE: Caller should hold chain lock.
+func (n *Node) checkBlockThrottle(b Block) error {
+	if n.Throttle.enabled() && n.budgetOf(b.Header.GeneratorID, b.Header.Timestamp).Remaining == 0 {
+		return ErrGeneratorThrottled
+	}
+
+	return nil
+}
+
+// CheckBlockThrottle ... Check if generator of block from other node has budget left on top of chain of node.
+func (n *Node) CheckBlockThrottle(b Block) error {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.checkBlockThrottle(b)
+}
+
+// BudgetOf ... Get current block budget of generator.
+func (n *Node) BudgetOf(generatorID []byte) GeneratorBudget {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	return n.budgetOf(generatorID, int(time.Now().Unix()))
+}
+
+// GetBudgets ... Get current block budgets of node and every generator in chain.
+func (n *Node) GetBudgets() []GeneratorBudget {
+	n.ChainLock.RLock()
+	defer n.ChainLock.RUnlock()
+
+	now := int(time.Now().Unix())
+
+	generators := map[string][]byte{Base58Encode(n.PublicKey()): n.PublicKey()}
+	for _, id := range n.Index.Generators() {
+		generators[Base58Encode(id)] = id
+	}
+
+	var timestampsOf map[string][]int
+	if n.Throttle.enabled() {
+		timestampsOf = n.blocksInWindow(now - n.Throttle.window())
+	}
+
+	var budgets []GeneratorBudget
+
+	for key, id := range generators {
+		budgets = append(budgets, n.Throttle.budget(id, timestampsOf[key]))
+	}
+
+	sort.Slice(budgets, func(i, j int) bool {
+		return bytes.Compare(budgets[i].GeneratorID, budgets[j].GeneratorID) < 0
+	})
+
+	return budgets
+}
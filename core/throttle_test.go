@@ -0,0 +1,82 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Test throttling of block generators.
+func TestThrottle(t *testing.T) {
+	n, _ := NewNode("127.0.0.1", 0)
+	n.Throttle = ThrottleConfig{Window: time.Hour, MaxBlocks: 2}
+
+	if gb := n.BudgetOf(n.PublicKey()); gb.Used != 0 || gb.Remaining != 2 || gb.ResetAt != 0 {
+		panic(fmt.Errorf("(*Node) BudgetOf() testing failed"))
+	}
+
+	for i := 0; i < 3; i++ {
+		m, _ := NewNode("127.0.0.1", 0)
+		n.CheckAndAddTransactionToPool(m.NewGenesisTransaction([]byte("genesis")))
+
+		b, _ := n.ProduceBlock()
+		if b != (i < 2) {
+			panic(fmt.Errorf("(*Node) ProduceBlock() should keep within block budget"))
+		}
+	}
+
+	gb := n.BudgetOf(n.PublicKey())
+	if gb.Used != 2 || gb.Remaining != 0 || gb.ResetAt == 0 {
+		panic(fmt.Errorf("(*Node) BudgetOf() should use up budget"))
+	}
+
+	if gbs := n.GetBudgets(); len(gbs) != 1 || !bytes.Equal(gbs[0].GeneratorID, n.PublicKey()) {
+		panic(fmt.Errorf("(*Node) GetBudgets() testing failed"))
+	}
+
+	// Block beyond budget is rejected, either incomming or in history.
+	_, last := n.GetLastBlock()
+	block, _ := n.NewBlock(last.ID(), nil)
+
+	if err := n.CheckBlockThrottle(block); err != ErrGeneratorThrottled {
+		panic(fmt.Errorf("(*Node) CheckBlockThrottle() should reject block beyond budget, got %v", err))
+	}
+
+	n.Throttle.MaxBlocks = 1
+
+	var verr *ValidationError
+	if err := n.ValidateChain(); !errors.As(err, &verr) || verr.BlockIndex != 1 {
+		panic(fmt.Errorf("(*Node) ValidateChain() should reject generator beyond budget"))
+	}
+
+	// Window slides past old blocks.
+	n.Throttle.Window = time.Second
+	block.Header.Timestamp += 2
+	if err := n.Chain.CheckBlockThrottle(block, n.Throttle); err != nil {
+		panic(err)
+	}
+
+	// Time index of main chain agrees with scanning chain.
+	if err := n.CheckBlockThrottle(block); err != nil {
+		panic(err)
+	}
+
+	for _, now := range []int{last.Header.Timestamp, last.Header.Timestamp + 1, last.Header.Timestamp + 2} {
+		n.ChainLock.RLock()
+		indexed, scanned := n.budgetOf(n.PublicKey(), now), n.Chain.BudgetOf(n.PublicKey(), now, n.Throttle)
+		n.ChainLock.RUnlock()
+
+		if indexed.Used != scanned.Used || indexed.Remaining != scanned.Remaining || indexed.ResetAt != scanned.ResetAt {
+			panic(fmt.Errorf("(*Node) budgetOf() = %v, want %v", indexed, scanned))
+		}
+	}
+
+	gbjson, _ := gb.MarshalJSON()
+
+	var gb2 GeneratorBudget
+	if err := gb2.UnmarshalJSON(gbjson); err != nil || !bytes.Equal(gb.GeneratorID, gb2.GeneratorID) || gb.ResetAt != gb2.ResetAt {
+		panic(fmt.Errorf("(GeneratorBudget) MarshalJSON() testing failed"))
+	}
+}
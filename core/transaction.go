@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 )
 
 // TXOutput ... The output field contains the following 2 entries:
@@ -193,6 +194,44 @@ func (t Transaction) Hash() []byte {
 	return SHA256(t.Meta)
 }
 
+// RequesterHash ... Get hash signed by requester.
+// It covers everything but the decision of requestee, i.e. requestee signature and output.
+func (t Transaction) RequesterHash() []byte {
+	return SHA256(JoinBytesWithLength(
+		t.ID(),
+		UInt64ToBytes(uint64(t.Timestamp())),
+		t.PreviousID(),
+		t.RequesterPK(),
+		t.RequesteePK(),
+		t.Meta))
+}
+
+// RequesteeHash ... Get hash signed by requestee.
+// It covers requester signature and output, so credits can't be changed once transaction is confirmed.
+func (t Transaction) RequesteeHash() []byte {
+	return SHA256(JoinBytesWithLength(
+		t.RequesterHash(),
+		t.RequesterSig(),
+		UInt64ToBytes(uint64(t.Accepted())),
+		UInt64ToBytes(uint64(t.Rejected()))))
+}
+
+// Serialize ... Encode transaction into canonical bytes.
+// The encoding is independent of Json, so it's used for hashing.
+func (t Transaction) Serialize() []byte {
+	return JoinBytesWithLength(
+		t.ID(),
+		UInt64ToBytes(uint64(t.Timestamp())),
+		t.PreviousID(),
+		t.RequesterPK(),
+		t.RequesterSig(),
+		t.RequesteePK(),
+		t.RequesteeSig(),
+		t.Meta,
+		UInt64ToBytes(uint64(t.Accepted())),
+		UInt64ToBytes(uint64(t.Rejected())))
+}
+
 // EqualWith ... Test if two transactions are equal.
 func (t Transaction) EqualWith(temp Transaction) bool {
 	if !t.Header.EqualWith(temp.Header) {
@@ -251,12 +290,12 @@ func (t Transaction) VerifyTransactionID() bool {
 
 // VerifyRequesterSig ... Verify requester signature.
 func (t Transaction) VerifyRequesterSig() bool {
-	return VerifySignature(t.RequesterPK(), t.RequesterSig(), t.Hash())
+	return VerifySignature(t.RequesterPK(), t.RequesterSig(), t.RequesterHash())
 }
 
 // VerifyRequesteeSig ... Verify requestee signature.
 func (t Transaction) VerifyRequesteeSig() bool {
-	return VerifySignature(t.RequesteePK(), t.RequesteeSig(), t.Hash())
+	return VerifySignature(t.RequesteePK(), t.RequesteeSig(), t.RequesteeHash())
 }
 
 // IsGenesisTransaction ... Test if it's genesis transaction.
@@ -264,6 +303,58 @@ func (t Transaction) IsGenesisTransaction() bool {
 	return bytes.Equal(t.ID(), t.PreviousID())
 }
 
+// IsEarlierThan ... Test if transaction is earlier than given one, ties are broken by id.
+func (t Transaction) IsEarlierThan(temp Transaction) bool {
+	if t.Timestamp() != temp.Timestamp() {
+		return t.Timestamp() < temp.Timestamp()
+	}
+
+	return bytes.Compare(t.ID(), temp.ID()) < 0
+}
+
+// Errors of credit verification.
+var (
+	ErrUnknownPrevTransaction = errors.New("Previous transaction is unknown")
+	ErrInvalidPrevTransaction = errors.New("Previous transaction is not generated by requester")
+	ErrInvalidCredits         = errors.New("Credits don't advance from previous transaction")
+	ErrForkedTransaction      = errors.New("Another transaction claims the same previous transaction")
+	ErrSelfConfirmed          = errors.New("Requester could not be requestee of its own transaction")
+)
+
+// VerifyGenesisCredits ... Verify credits of genesis transaction, history of requester starts with one acceptance.
+func VerifyGenesisCredits(t Transaction) error {
+	if t.Accepted() != 1 || t.Rejected() != 0 {
+		return ErrInvalidCredits
+	}
+
+	return nil
+}
+
+// VerifyCredits ... Verify that transaction continues given previous transaction of requester.
+// Either accepted or rejected number advances by exactly one, and only another node could decide it.
+func VerifyCredits(prev, t Transaction) error {
+	if bytes.Equal(t.RequesterPK(), t.RequesteePK()) {
+		return ErrSelfConfirmed
+	}
+
+	if !bytes.Equal(prev.RequesterPK(), t.RequesterPK()) {
+		return ErrInvalidPrevTransaction
+	}
+
+	if t.Timestamp() < prev.Timestamp() {
+		return ErrInvalidPrevTransaction
+	}
+
+	accepted := t.Accepted() == prev.Accepted()+1 && t.Rejected() == prev.Rejected()
+	rejected := t.Accepted() == prev.Accepted() && t.Rejected() == prev.Rejected()+1
+
+	if !accepted && !rejected {
+		return ErrInvalidCredits
+	}
+
+	return nil
+}
+
 // TransactionSlice ...
 type TransactionSlice []Transaction
 
@@ -271,9 +362,8 @@ func (ts TransactionSlice) Len() int           { return len(ts) }
 func (ts TransactionSlice) Swap(i, j int)      { ts[i], ts[j] = ts[j], ts[i] }
 func (ts TransactionSlice) Less(i, j int) bool { return ts[i].Timestamp() < ts[j].Timestamp() }
 
-// TODO: We have 2 ways to represent transactions in a block
-// (1) TransactionSlice: Consume lower memory, but low performace
-// (2) TransactionsMap: High performance, but consume more memory
+// NOTE: Blocks keep transactions in TransactionSlice, which consumes lower memory,
+// and lookups in chain of node go through ChainIndex instead of scanning blocks.
 
 // Contains ... Test if given tansaction is contained in the trs.
 func (ts TransactionSlice) Contains(tr Transaction) (bool, int) {
@@ -286,6 +376,17 @@ func (ts TransactionSlice) Contains(tr Transaction) (bool, int) {
 	return false, 0
 }
 
+// GetTransactionByPrevID ... Get non-genesis transaction which claims given previous transaction.
+func (ts TransactionSlice) GetTransactionByPrevID(prevID []byte) (bool, Transaction) {
+	for _, tr := range ts {
+		if !tr.IsGenesisTransaction() && bytes.Equal(tr.PreviousID(), prevID) {
+			return true, tr
+		}
+	}
+
+	return false, Transaction{}
+}
+
 // ContainsByID ... Test if transaction with given id is contained in the trs.
 func (ts TransactionSlice) ContainsByID(id []byte) (bool, int) {
 	for i, t := range ts {
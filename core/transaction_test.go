@@ -162,9 +162,8 @@ func TestVerifySignature(t *testing.T) {
 	tr.Header.RequesterPublicKey = kp.Public
 	tr.Header.RequesteePublicKey = kp.Public
 
-	Sig, _ := kp.Sign(tr.Hash())
-	tr.Header.RequesterSignature = Sig
-	tr.Header.RequesteeSignature = Sig
+	tr.Header.RequesterSignature, _ = kp.Sign(tr.RequesterHash())
+	tr.Header.RequesteeSignature, _ = kp.Sign(tr.RequesteeHash())
 
 	if !tr.VerifyRequesterSig() {
 		panic(fmt.Errorf("(Transaction) VerifyRequesterSig() testing failed"))
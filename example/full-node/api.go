@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"html/template"
 	"net/http"
 	"strconv"
@@ -21,8 +20,18 @@ func (c *client) runWebServer(port int) {
 	http.HandleFunc(apiURL+"nodes", c.getNodesHandler)
 	http.HandleFunc(apiURL+"pendings", c.getPendingTransactionsHandler)
 	http.HandleFunc(apiURL+"transactions", c.getTransactionsHandler)
+	http.HandleFunc(apiURL+"blocks", c.getBlocksHandler)
+	http.HandleFunc(apiURL+"block", c.getBlockHandler)
+	http.HandleFunc(apiURL+"transaction", c.getTransactionHandler)
+	http.HandleFunc(apiURL+"history", c.getHistoryHandler)
+	http.HandleFunc(apiURL+"headers", c.getHeadersHandler)
+	http.HandleFunc(apiURL+"proof", c.getMerkleProofHandler)
+	http.HandleFunc(apiURL+"reputations", c.getReputationsHandler)
+	http.HandleFunc(apiURL+"cluster", c.getClusterHandler)
+	http.HandleFunc(apiURL+"budgets", c.getBudgetsHandler)
 
 	http.HandleFunc(apiURL+"confirm", c.confirmPendingTransactionHandler)
+	http.HandleFunc(apiURL+"reject", c.rejectPendingTransactionHandler)
 	http.HandleFunc(apiURL+"send_transaction", c.sendTransactionHandler)
 
 	http.HandleFunc("/", c.indexHandler)
@@ -45,7 +54,7 @@ func (c *client) getNodesHandler(w http.ResponseWriter, r *http.Request) {
 	_, ns := c.node.GetNodesOfRoutingTable()
 
 	nsjson, _ := json.Marshal(ns)
-	fmt.Fprintf(w, string(nsjson))
+	w.Write(nsjson)
 }
 
 func (c *client) getPendingTransactionsHandler(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +62,7 @@ func (c *client) getPendingTransactionsHandler(w http.ResponseWriter, r *http.Re
 	_, ts := c.node.GetPendingTransactions()
 
 	tsjson, _ := json.Marshal(ts)
-	fmt.Fprintf(w, string(tsjson))
+	w.Write(tsjson)
 }
 
 func (c *client) getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
@@ -61,7 +70,176 @@ func (c *client) getTransactionsHandler(w http.ResponseWriter, r *http.Request)
 	_, ts := c.node.GetTransactionsOfPool()
 
 	tsjson, _ := json.Marshal(ts)
-	fmt.Fprintf(w, string(tsjson))
+	w.Write(tsjson)
+}
+
+func (c *client) getBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// Blocks within time range, if it's given.
+	if q.Get("from") != "" || q.Get("to") != "" {
+		from, err1 := strconv.Atoi(q.Get("from"))
+		to, err2 := strconv.Atoi(q.Get("to"))
+		if err1 != nil || err2 != nil {
+			http.Error(w, "Invalid time range", http.StatusBadRequest)
+			return
+		}
+
+		bsjson, _ := json.Marshal(c.node.GetBlocksByTime(from, to))
+		w.Write(bsjson)
+		return
+	}
+
+	_, bs := c.node.GetBlocksOfChain()
+
+	bsjson, _ := json.Marshal(bs)
+	w.Write(bsjson)
+}
+
+func (c *client) getBlockHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var b bool
+	var block core.Block
+
+	if id := q.Get("id"); id != "" {
+		b, block = c.node.GetBlockByID(core.Base58Decode(id))
+	} else {
+		height, err := strconv.Atoi(q.Get("height"))
+		if err != nil {
+			http.Error(w, "Invalid block height", http.StatusBadRequest)
+			return
+		}
+
+		b, block = c.node.GetBlockByHeight(height)
+	}
+
+	if !b {
+		http.NotFound(w, r)
+		return
+	}
+
+	bjson, _ := json.Marshal(block)
+	w.Write(bjson)
+}
+
+func (c *client) getTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	id := core.Base58Decode(r.URL.Query().Get("id"))
+	if len(id) == 0 {
+		http.Error(w, "Invalid transaction id", http.StatusBadRequest)
+		return
+	}
+
+	b, t := c.node.GetTransactionByIDFromChain(id)
+	if !b {
+		http.NotFound(w, r)
+		return
+	}
+
+	tjson, _ := json.Marshal(t)
+	w.Write(tjson)
+}
+
+func (c *client) getHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var ts core.TransactionSlice
+
+	if pk := q.Get("requester"); pk != "" {
+		ts = c.node.GetTransactionsByRequester(core.Base58Decode(pk))
+	} else if pk := q.Get("requestee"); pk != "" {
+		ts = c.node.GetTransactionsByRequestee(core.Base58Decode(pk))
+	} else {
+		http.Error(w, "Requester or requestee is required", http.StatusBadRequest)
+		return
+	}
+
+	tsjson, _ := json.Marshal(ts)
+	w.Write(tsjson)
+}
+
+func (c *client) getHeadersHandler(w http.ResponseWriter, r *http.Request) {
+
+	_, hs := c.node.GetSignedHeaders()
+
+	hsjson, _ := json.Marshal(hs)
+	w.Write(hsjson)
+}
+
+func (c *client) getMerkleProofHandler(w http.ResponseWriter, r *http.Request) {
+	id := core.Base58Decode(r.URL.Query().Get("id"))
+	if len(id) == 0 {
+		http.Error(w, "Invalid transaction id", http.StatusBadRequest)
+		return
+	}
+
+	// Light node keeps no transactions, it asks other nodes and verifies their proofs.
+	var pd core.ProofData
+
+	if c.node.Light {
+		pd, _ = c.node.RequestProofFromPeers(id)
+	} else {
+		pd = c.node.GetProof(id)
+	}
+
+	if !pd.Found {
+		http.NotFound(w, r)
+		return
+	}
+
+	pjson, _ := json.Marshal(&struct {
+		Header      core.BlockHeader `json:"header"`
+		Proof       core.MerkleProof `json:"proof"`
+		Transaction core.Transaction `json:"transaction"`
+	}{pd.Header, pd.Proof, pd.Transaction})
+	w.Write(pjson)
+}
+
+func (c *client) getReputationsHandler(w http.ResponseWriter, r *http.Request) {
+	if id := r.URL.Query().Get("id"); id != "" {
+		pk := core.Base58Decode(id)
+		if len(pk) == 0 {
+			http.Error(w, "Invalid public key", http.StatusBadRequest)
+			return
+		}
+
+		rjson, _ := json.Marshal(c.node.ReputationOf(pk))
+		w.Write(rjson)
+		return
+	}
+
+	rsjson, _ := json.Marshal(c.node.GetReputations())
+	w.Write(rsjson)
+}
+
+func (c *client) getBudgetsHandler(w http.ResponseWriter, r *http.Request) {
+	if id := r.URL.Query().Get("id"); id != "" {
+		pk := core.Base58Decode(id)
+		if len(pk) == 0 {
+			http.Error(w, "Invalid generator id", http.StatusBadRequest)
+			return
+		}
+
+		bjson, _ := json.Marshal(c.node.BudgetOf(pk))
+		w.Write(bjson)
+		return
+	}
+
+	bsjson, _ := json.Marshal(c.node.GetBudgets())
+	w.Write(bsjson)
+}
+
+func (c *client) getClusterHandler(w http.ResponseWriter, r *http.Request) {
+
+	_, members := c.node.GetClusterMembers()
+
+	cjson, _ := json.Marshal(&struct {
+		Name    string            `json:"name"`
+		Head    string            `json:"head"`
+		IsHead  bool              `json:"is_head"`
+		Members []core.RemoteNode `json:"members"`
+	}{c.node.ClusterName(), core.Base58Encode(c.node.ClusterHead()), c.node.IsClusterHead(), members})
+	w.Write(cjson)
 }
 
 func (c *client) confirmPendingTransactionHandler(w http.ResponseWriter, r *http.Request) {
@@ -85,6 +263,22 @@ func (c *client) confirmPendingTransactionHandler(w http.ResponseWriter, r *http
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+func (c *client) rejectPendingTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		r.ParseForm()
+
+		if r.Form["pending_id"] != nil {
+			pendingIDBytes := core.Base58Decode(r.Form["pending_id"][0])
+
+			if len(pendingIDBytes) != 0 {
+				c.rejectPendingTransaction(pendingIDBytes)
+			}
+		}
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
 func (c *client) sendTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		r.ParseForm()
@@ -100,6 +294,12 @@ func (c *client) sendTransactionHandler(w http.ResponseWriter, r *http.Request)
 			}
 
 			if !bytes.Equal(nodeIDBytes, c.node.PublicKey()) {
+				if c.node.PrevTransaction() == nil {
+					// Genesis transaction should be generated first.
+					http.Redirect(w, r, "/", http.StatusSeeOther)
+					return
+				}
+
 				t := c.newPendingTransaction(nodeIDBytes, data)
 
 				go c.sendPendingTransaction(t)
@@ -115,5 +315,7 @@ func (c *client) sendTransactionHandler(w http.ResponseWriter, r *http.Request)
 func (c *client) checkAndProcessPendingTransaction(id []byte, confirm string) {
 	if confirm == "1" {
 		c.confirmPendingTransaction(id)
+	} else if confirm == "0" {
+		c.rejectPendingTransaction(id)
 	}
 }
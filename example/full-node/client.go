@@ -1,23 +1,34 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/vgxbj/microchain/core"
 )
 
 type client struct {
-	node     *core.Node
-	terminal chan string
-	logger   *core.Logger
-	webport  int
+	node      *core.Node
+	terminal  chan string
+	logger    *core.Logger
+	webport   int
+	syncLock  sync.Mutex                 // Sync chain from one peer at a time
+	peerLock  sync.Mutex                 // Lock of syncing
+	syncing   map[string]bool            // Addresses of peers that chain is being synced from
+	keyFile   string                     // File that key pair is kept in, empty if there is none
+	keyFormat string                     // Format of key file
+	synced    chan core.TransactionSlice // Synced transactions waiting for verification
 }
 
 // Callback functions.
 var resp = map[byte]func(core.IncommingMessage, *client){
 	core.Ping: pingResp,
 
+	core.Join: joinResp,
+
 	core.SyncNodes: syncNodesResp,
 
 	core.SyncTransactions: syncTransactionsResp,
@@ -25,23 +36,108 @@ var resp = map[byte]func(core.IncommingMessage, *client){
 	core.SendTransaction: sendTransactionResp,
 
 	core.PendingTransaction: pendingTransactionResp,
+
+	core.AnnounceTip: announceTipResp,
+
+	core.GetHeaders: getHeadersResp,
+
+	core.GetBlocks: getBlocksResp,
+
+	core.GetProof: getProofResp,
+
+	core.KeyRotation: keyRotationResp,
+}
+
+// Generate new node, restore it from data directory if given.
+func newNode(ip string, nodePort int, dataDir string, light bool, l *core.Logger) (*core.Node, error) {
+	if dataDir == "" {
+		n, err := core.NewNode(ip, nodePort)
+		if err != nil {
+			return nil, err
+		}
+
+		n.Light = light
+
+		return n, nil
+	}
+
+	s, err := core.NewFileStore(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := core.NewNodeWithStore(ip, nodePort, s)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	n.Light = light
+
+	n.StoreErrorHandler = func(err error) {
+		l.Error.Println(err)
+	}
+
+	return n, nil
+}
+
+// Load key pair of node from key file, or save key pair of node into it if it does not exist yet,
+// node key pair is replaced by a new one if it's not of given key type.
+// Passphrase of key file is read from environment.
+func useKeyFile(n *core.Node, path, format, keyType string) error {
+	t, err := core.ParseKeyType(keyType)
+	if err != nil {
+		return err
+	}
+
+	return n.UseKeyPairFile(path, format, []byte(os.Getenv(keyPassphraseEnv)), t)
 }
 
 // Generate new client.
-func newClient(ip string, nodePort, webPort int, l *core.Logger) (*client, error) {
+func newClient(ip string, nodePort, webPort int, dataDir string, light bool, keyFile, keyFormat, keyType string, l *core.Logger) (*client, error) {
 	// new client
-	n, err := core.NewNode(ip, nodePort)
+	n, err := newNode(ip, nodePort, dataDir, light, l)
 	if err != nil {
 		return nil, err
 	}
 
+	if keyFile != "" {
+		err = useKeyFile(n, keyFile, keyFormat, keyType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c := &client{
-		node:     n,
-		terminal: make(chan string),
-		logger:   l,
-		webport:  webPort,
+		node:      n,
+		terminal:  make(chan string),
+		logger:    l,
+		webport:   webPort,
+		keyFile:   keyFile,
+		keyFormat: keyFormat,
+		synced:    make(chan core.TransactionSlice, syncTransactionsBacklog),
+		syncing:   make(map[string]bool),
+	}
+
+	// Gossip should be signed by the peer who sends it, and never replayed.
+	c.node.MessagePolicy = core.ChainPolicies(
+		core.SenderPolicy,
+		core.NewSignaturePolicy(messageMaxAge*time.Second, core.SyncNodes, core.SyncTransactions, core.AnnounceTip, core.KeyRotation))
+
+	c.node.Consensus = core.ConsensusConfig{
+		MinWait:    blockMinWait * time.Second,
+		MaxWait:    blockMaxWait * time.Second,
+		MinSpacing: blockMinSpacing * time.Second,
+	}
+
+	c.node.Throttle = core.ThrottleConfig{
+		Window:    throttleWindow * time.Second,
+		MaxBlocks: throttleMaxBlocks,
 	}
 
+	// Competing branches are decided by trust of their generators.
+	c.node.ForkChoice = core.MostTrustedChain
+
 	// initialize network.
 	err = c.node.Run()
 	if err != nil {
@@ -54,6 +150,9 @@ func newClient(ip string, nodePort, webPort int, l *core.Logger) (*client, error
 	// process incomming message.
 	go c.processIncommingMessage()
 
+	// verify synced transactions.
+	go c.verifySyncedTransactions()
+
 	// initialize web server.
 	go c.runWebServer(webPort)
 
@@ -66,6 +165,9 @@ func newClient(ip string, nodePort, webPort int, l *core.Logger) (*client, error
 	// broadcast transactions
 	go c.broadcastTransactionsPool()
 
+	// seal transactions into blocks.
+	go c.node.RunBlockProducer()
+
 	return c, nil
 }
 
@@ -77,7 +179,14 @@ func (c *client) processIncommingMessage() {
 			resp[m.Content.Type](m, c)
 		}
 
-		m.Conn.Close()
+		m.Close()
+	}
+}
+
+// Verify synced transactions, and add valid ones into transactions pool.
+func (c *client) verifySyncedTransactions() {
+	for trs := range c.synced {
+		c.node.CheckAndAddTransactionsToPool(trs)
 	}
 }
 
@@ -90,6 +199,11 @@ func pingResp(m core.IncommingMessage, c *client) {
 		return
 	}
 
+	// Sender can only ping as itself.
+	if !bytes.Equal(p.PublicKey, m.SenderPK()) {
+		return
+	}
+
 	// Test if node is existed in routing table, if not, add it into routing table.
 	if !c.node.IsInRoutingTable(p.PublicKey) {
 		c.node.CheckAndAddNodeToRoutingTable(core.RemoteNode{
@@ -97,6 +211,8 @@ func pingResp(m core.IncommingMessage, c *client) {
 			Address:    p.Address,
 			Lastseen:   int(time.Now().Unix()),
 			VerifiedBy: nil,
+			Cluster:    p.Cluster,
+			Head:       p.Head,
 		})
 	} else {
 		// Update lastseen value.
@@ -107,11 +223,37 @@ func pingResp(m core.IncommingMessage, c *client) {
 		}
 
 		rn.Lastseen = int(time.Now().Unix())
+		rn.Cluster = p.Cluster
+		rn.Head = p.Head
 
 		c.node.UpdateNodeForGivenPublicKey(rn.PublicKey, rn)
 	}
 
-	m.Conn.Write([]byte("pong"))
+	m.Reply([]byte("pong"))
+}
+
+// Callback function for join request.
+func joinResp(m core.IncommingMessage, c *client) {
+	var j core.JoinData
+
+	err := j.UnmarshalJson(m.Content.Data)
+	if err != nil {
+		return
+	}
+
+	// Sender can only join as itself.
+	if !bytes.Equal(j.PublicKey, m.SenderPK()) {
+		return
+	}
+
+	reply := c.node.AcceptJoin(j)
+
+	rjson, err := reply.MarshalJson()
+	if err != nil {
+		return
+	}
+
+	m.Reply(rjson)
 }
 
 // Callback function for sync nodes.
@@ -138,9 +280,11 @@ func syncTransactionsResp(m core.IncommingMessage, c *client) {
 		return
 	}
 
-	for _, tr := range st.Transactions {
-		// TODO: check transactions.
-		c.node.CheckAndAddTransactionToPool(tr)
+	// Verification is left to another goroutine, so burst of transactions never blocks other messages.
+	// Transactions pool is broadcasted periodically, so batch is dropped if too many are waiting.
+	select {
+	case c.synced <- st.Transactions:
+	default:
 	}
 }
 
@@ -155,9 +299,145 @@ func sendTransactionResp(m core.IncommingMessage, c *client) {
 
 	t := st.Transaction
 
+	// Node outside of cluster heads keeps no ledger, it only follows its own transactions.
+	if !c.node.IsClusterHead() {
+		if c.node.IsContinuationOfPrevTransaction(t) {
+			c.node.UpdatePrevTransaction(t)
+		}
+
+		return
+	}
+
 	if c.node.VerifyTransaction(t) {
 		// Add it into transactions pool
 		c.node.CheckAndAddTransactionToPool(t)
+
+		// Our transaction is confirmed, following transactions continue it.
+		prev := c.node.PrevTransaction()
+		if prev != nil && bytes.Equal(t.RequesterPK(), c.node.PublicKey()) && bytes.Equal(t.PreviousID(), prev.ID()) {
+			c.node.UpdatePrevTransaction(t)
+		}
+	}
+}
+
+// Callback for announce tip.
+func announceTipResp(m core.IncommingMessage, c *client) {
+	var td core.TipData
+
+	err := td.UnmarshalJson(m.Content.Data)
+	if err != nil {
+		return
+	}
+
+	// Sender can only announce its own chain.
+	if !bytes.Equal(td.PublicKey, m.SenderPK()) {
+		return
+	}
+
+	// Address in announcement is not authenticated, sync from the address we know for sender.
+	b, sender := c.node.GetNodeByPublicKey(m.SenderPK())
+	if !b {
+		return
+	}
+
+	addr := sender.Addr()
+
+	c.peerLock.Lock()
+	if c.syncing[addr] {
+		c.peerLock.Unlock()
+		return
+	}
+	c.syncing[addr] = true
+	c.peerLock.Unlock()
+
+	go func() {
+		defer func() {
+			c.peerLock.Lock()
+			delete(c.syncing, addr)
+			c.peerLock.Unlock()
+		}()
+
+		c.syncChain(addr, td.TipID)
+	}()
+}
+
+// Callback for get headers.
+func getHeadersResp(m core.IncommingMessage, c *client) {
+	var gh core.GetHeadersData
+
+	err := gh.UnmarshalJson(m.Content.Data)
+	if err != nil {
+		return
+	}
+
+	hjson, err := core.HeadersData{Headers: c.node.GetHeaders(gh.From, gh.Count)}.MarshalJson()
+	if err != nil {
+		return
+	}
+
+	m.Reply(hjson)
+}
+
+// Callback for get blocks.
+func getBlocksResp(m core.IncommingMessage, c *client) {
+	var gb core.GetBlocksData
+
+	err := gb.UnmarshalJson(m.Content.Data)
+	if err != nil {
+		return
+	}
+
+	bjson, err := core.BlocksData{Blocks: c.node.GetBlocksByID(gb.IDs)}.MarshalJson()
+	if err != nil {
+		return
+	}
+
+	m.Reply(bjson)
+}
+
+// Callback for get proof.
+func getProofResp(m core.IncommingMessage, c *client) {
+	var gp core.GetProofData
+
+	err := gp.UnmarshalJson(m.Content.Data)
+	if err != nil {
+		return
+	}
+
+	pjson, err := c.node.GetProof(gp.TransactionID).MarshalJson()
+	if err != nil {
+		return
+	}
+
+	m.Reply(pjson)
+}
+
+// Callback for key rotation.
+func keyRotationResp(m core.IncommingMessage, c *client) {
+	var kr core.KeyRotationData
+
+	err := kr.UnmarshalJson(m.Content.Data)
+	if err != nil {
+		return
+	}
+
+	t := kr.Transaction
+
+	// Only the old key announces its own rotation.
+	if !bytes.Equal(t.RequesteePK(), m.SenderPK()) {
+		return
+	}
+
+	err = c.node.AcceptKeyRotation(t)
+	if err != nil {
+		c.logger.Error.Println(err)
+		return
+	}
+
+	c.logger.Info.Println("Node", core.Base58Encode(t.RequesteePK()), "rotated its key to", core.Base58Encode(t.RequesterPK()))
+
+	if c.node.VerifyTransaction(t) {
+		c.node.CheckAndAddTransactionToPool(t)
 	}
 }
 
@@ -177,8 +457,17 @@ func pendingTransactionResp(m core.IncommingMessage, c *client) {
 		return
 	}
 
-	if !c.node.IsInPendingTransactions(t.ID()) {
-		c.node.CheckAndAddPendingTransaction(t)
+	if c.node.IsInPendingTransactions(t.ID()) {
+		return
+	}
+
+	c.node.CheckAndAddPendingTransaction(t)
+
+	// Requester with bad record is rejected without asking.
+	// Rejection is published in another goroutine, so unreachable peers never block other messages.
+	if !c.node.IsTrusted(t.RequesterPK(), minTrustScore) {
+		c.logger.Info.Println("Reject pending transaction from untrusted requester", core.Base58Encode(t.RequesterPK()))
+		go c.rejectPendingTransaction(t.ID())
 	}
 }
 
@@ -189,7 +478,7 @@ func (c *client) maintainRoutingTable() {
 
 		_, nodes := c.node.GetNodesOfRoutingTable()
 
-		p := core.NewPingMessage(c.node.PublicKey(), c.node.Addr())
+		p := c.node.SignMessage(c.node.NewPingMessage())
 		pjson, err := p.MarshalJson()
 		if err != nil {
 			continue
@@ -217,7 +506,7 @@ func (c *client) broadcastKnownNodes() {
 
 		nodes := c.collectNodesFromRoutingTable()
 
-		m := core.NewSyncNodesMessage(nodes)
+		m := c.node.SignMessage(core.NewSyncNodesMessage(nodes))
 
 		mjson, err := m.MarshalJson()
 		if err != nil {
@@ -233,16 +522,23 @@ func (c *client) broadcastTransactionsPool() {
 	for {
 		time.Sleep(broadcastTransactionsPoolPeriod * time.Second)
 
+		// Transactions pool is shared among cluster heads only.
+		if !c.node.IsClusterHead() {
+			continue
+		}
+
 		_, ts := c.node.GetTransactionsOfPool()
 
-		m := core.NewSyncTransactionsMessage(ts)
+		m := c.node.SignMessage(core.NewSyncTransactionsMessage(ts))
 
 		mjson, err := m.MarshalJson()
 		if err != nil {
 			return
 		}
 
-		c.node.Broadcast(mjson, func([]byte) error { return nil })
+		_, heads := c.node.GetClusterHeads()
+
+		c.node.Multicast(heads, mjson, func([]byte) error { return nil })
 	}
 }
 
@@ -253,35 +549,14 @@ func (c *client) collectNodesFromRoutingTable() []core.RemoteNode {
 	_, nodes = c.node.GetNodesOfRoutingTable()
 
 	// Add client itself to []Node.
-	nodes = append(nodes, core.RemoteNode{
-		PublicKey: c.node.Keypair.Public,
-		Address:   c.node.Addr(),
-		Lastseen:  int(time.Now().Unix()),
-	})
+	nodes = append(nodes, c.node.Self())
 
 	return nodes
 }
 
 // Generate new pending transaction.
 func (c *client) newPendingTransaction(id []byte, data string) core.Transaction {
-	time := int(time.Now().Unix())
-
-	timeByte := core.UInt64ToBytes(uint64(time))
-
-	h := core.TransactionHeader{
-		TransactionID:      core.SHA256(core.JoinBytes(c.node.PublicKey(), id, timeByte)),
-		Timestamp:          time,
-		PrevTransactionID:  c.node.PrevTransaction().ID(),
-		RequesterPublicKey: c.node.PublicKey(),
-		RequesterSignature: c.node.Sign(core.SHA256([]byte(data))),
-		RequesteePublicKey: id,
-	}
-
-	meta := []byte(data)
-
-	txo := c.node.PrevTransaction().Out()
-
-	return core.Transaction{Header: h, Meta: meta, Output: txo}
+	return c.node.NewPendingTransaction(id, []byte(data))
 }
 
 // Confirm pending transaction.
@@ -291,23 +566,26 @@ func (c *client) confirmPendingTransaction(id []byte) {
 		return
 	}
 
-	t = c.node.SignTransaction(t)
+	c.publishTransaction(c.node.ConfirmTransaction(t))
 
-	m := core.NewSendTransactionMessage(t)
+	c.node.RemovePendingTransactionByID(id)
+}
 
-	mjson, err := m.MarshalJson()
-	if err != nil {
+// Reject pending transaction.
+func (c *client) rejectPendingTransaction(id []byte) {
+	b, t := c.node.GetPendingTransactionByID(id)
+	if !b {
 		return
 	}
 
-	c.node.Broadcast(mjson, func([]byte) error { return nil })
+	c.publishTransaction(c.node.RejectTransaction(t))
 
 	c.node.RemovePendingTransactionByID(id)
 }
 
 // Ping node.
 func (c *client) pingNode(addr string) error {
-	p := core.NewPingMessage(c.node.PublicKey(), c.node.Addr())
+	p := c.node.SignMessage(c.node.NewPingMessage())
 	pjson, err := p.MarshalJson()
 	if err != nil {
 		return err
@@ -328,9 +606,45 @@ func (c *client) pingNode(addr string) error {
 	return nil
 }
 
+// Join network through bootstrap node.
+func (c *client) joinNetwork(addr string) {
+	c.terminal <- fmt.Sprintf("join network through %s ...\n", addr)
+
+	reply, err := c.node.Join(addr)
+	if err != nil {
+		c.terminal <- err.Error() + "\n"
+		return
+	}
+
+	c.terminal <- fmt.Sprintf("Joined network through %s, %d nodes known, chain height of bootstrap node is %d\n",
+		core.Base58Encode(reply.PublicKey), len(reply.Nodes)+1, reply.Height)
+
+	c.syncChain(addr, reply.TipID)
+}
+
+// Sync chain from node at given address, if its tip is unknown.
+// Only cluster heads keep ledger, and light node keeps headers of it.
+func (c *client) syncChain(addr string, tipID []byte) {
+	if len(tipID) == 0 || !(c.node.IsClusterHead() || c.node.Light) || c.node.HasBlock(tipID) {
+		return
+	}
+
+	c.syncLock.Lock()
+	defer c.syncLock.Unlock()
+
+	n, err := c.node.SyncChain(addr)
+	if err != nil {
+		c.logger.Error.Println(err)
+	}
+
+	if n > 0 {
+		c.logger.Info.Printf("Synced %d blocks from %s\n", n, addr)
+	}
+}
+
 // Send pending transaction.
 func (c *client) sendPendingTransaction(t core.Transaction) {
-	p := core.NewPendingTransactionMessage(t)
+	p := c.node.SignMessage(core.NewPendingTransactionMessage(t))
 
 	pjson, err := p.MarshalJson()
 	if err != nil {
@@ -352,13 +666,56 @@ func (c *client) broadcastGenesisTransaction(data string) {
 
 	t := c.node.NewGenesisTransaction([]byte(data))
 
-	c.node.PreviousTransaction = &t
+	if !c.node.SetGenesisTransaction(t) {
+		return
+	}
+
+	c.publishTransaction(t)
+}
+
+// Publish decided transaction.
+// Cluster head adds it into pool and broadcasts it, other nodes forward it to their head and its requester.
+func (c *client) publishTransaction(t core.Transaction) {
+	m := c.node.SignMessage(core.NewSendTransactionMessage(t))
+
+	mjson, err := m.MarshalJson()
+	if err != nil {
+		return
+	}
+
+	if c.node.IsClusterHead() {
+		c.node.CheckAndAddTransactionToPool(t)
+		c.node.Broadcast(mjson, func([]byte) error { return nil })
+		return
+	}
+
+	var nodes []core.RemoteNode
+
+	if b, head := c.node.GetClusterHeadNode(); b {
+		nodes = append(nodes, head)
+	} else {
+		c.logger.Error.Println("Cluster head is unknown, transaction is not forwarded")
+	}
 
-	m := core.NewSendTransactionMessage(t)
+	if b, requester := c.node.GetNodeByPublicKey(t.RequesterPK()); b && !bytes.Equal(requester.PublicKey, c.node.ClusterHead()) {
+		nodes = append(nodes, requester)
+	}
+
+	c.node.Multicast(nodes, mjson, func([]byte) error { return nil })
+}
 
-	mjson, _ := m.MarshalJson()
+// Rotate key pair of node, and keep new key pair in key file if there is one.
+func (c *client) rotateKeyPair() error {
+	err := c.node.UpdateKeyPair()
+	if err != nil {
+		return err
+	}
+
+	if c.keyFile == "" {
+		return nil
+	}
 
-	c.node.Broadcast(mjson, func([]byte) error { return nil })
+	return core.SaveKeyPairFile(c.keyFile, c.node.KeyPair(), c.keyFormat, []byte(os.Getenv(keyPassphraseEnv)))
 }
 
 // Print loop
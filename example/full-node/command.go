@@ -17,6 +17,9 @@ var genesisOpt = regexp.MustCompile(`genesis`)
 var queryPendingJobsOpt = regexp.MustCompile(`pending`)
 var queryTransactionsOpt = regexp.MustCompile(`transactions`)
 var confirmReqOpt = regexp.MustCompile(`confirm`)
+var rejectReqOpt = regexp.MustCompile(`reject`)
+var queryBlocksOpt = regexp.MustCompile(`blocks`)
+var rotateKeyOpt = regexp.MustCompile(`rotate`)
 
 func checkQueryNodesCommand(s string) (bool, string) {
 	if s != "nodes" {
@@ -43,6 +46,23 @@ func checkPingNodeCommand(s string) (bool, string, []string) {
 	return true, "", addrs
 }
 
+func checkJoinNetworkCommand(s string) (bool, string, string) {
+	if !strings.HasPrefix(s, "join") {
+		return false, fmt.Sprintf("Unknown command: %s, do you mean: join ?\n", s), ""
+	}
+
+	// Remove `join`
+	s = strings.TrimSpace(s[4:])
+
+	addrs := addrRegex.FindAllString(s, -1)
+
+	if len(addrs) != 1 {
+		return false, "Do you mean: join addr ?\n", ""
+	}
+
+	return true, "", addrs[0]
+}
+
 func checkQueryPendingCommand(s string) (bool, string) {
 	if s != "pending" {
 		return false, fmt.Sprintf("Unknown command: %s, do you mean: pending ?\n", s)
@@ -51,6 +71,14 @@ func checkQueryPendingCommand(s string) (bool, string) {
 	return true, ""
 }
 
+func checkQueryBlocksCommand(s string) (bool, string) {
+	if s != "blocks" {
+		return false, fmt.Sprintf("Unknown command: %s, do you mean: blocks ?\n", s)
+	}
+
+	return true, ""
+}
+
 func checkQueryTransactionsCommand(s string) (bool, string) {
 	if s != "transactions" {
 		return false, fmt.Sprintf("Unknown command: %s, do you mean: transactions ?\n", s)
@@ -111,3 +139,27 @@ func checkConfirmCommand(s string) (bool, string, []byte) {
 
 	return true, "", id
 }
+
+func checkRejectCommand(s string) (bool, string, []byte) {
+	if !strings.HasPrefix(s, "reject") {
+		return false, fmt.Sprintf("Unknown command: %s, do you mean: reject ?\n", s), []byte{}
+	}
+
+	// Remove `reject`
+	s = strings.TrimSpace(s[6:])
+
+	id := core.Base58Decode(s)
+	if len(id) == 0 {
+		return false, fmt.Sprintf("Invalid transaction id"), []byte{}
+	}
+
+	return true, "", id
+}
+
+func checkRotateKeyCommand(s string) (bool, string) {
+	if s != "rotate" {
+		return false, fmt.Sprintf("Unknown command: %s, do you mean: rotate ?\n", s)
+	}
+
+	return true, ""
+}
@@ -5,6 +5,17 @@ const (
 	pingPeriod                      = 5  // Ping other nodes, every 5 seconds.
 	broadcastRoutingTablePeriod     = 7  // Broadcast routing table, every 7 seconds.
 	broadcastTransactionsPoolPeriod = 7  // Broadcast transactions pool, every 7 seconds.
+	blockMinWait                    = 5  // Wait at least 5 seconds before producing block.
+	blockMaxWait                    = 15 // Wait at most 15 seconds before producing block.
+	blockMinSpacing                 = 10 // Blocks of the same generator are at least 10 seconds apart.
+	throttleWindow                  = 60 // Blocks of generator are counted in a sliding window of 60 seconds.
+	throttleMaxBlocks               = 5  // Generator produces at most 5 blocks in window.
+	messageMaxAge                   = 60 // Signed message older than 60 seconds is rejected.
+	syncTransactionsBacklog         = 16 // At most 16 synced batches of transactions wait for verification.
+
+	minTrustScore = 0.3 // Pending transaction from requester scoring less than 0.3 is rejected.
+
+	keyPassphraseEnv = "MICROCHAIN_KEY_PASSPHRASE" // Environment variable holding passphrase of key file.
 
 	apiVersion = "v1" // API version
 )
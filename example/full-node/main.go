@@ -10,6 +10,14 @@ import (
 var nodeIPOpt = flag.String("addr", "localhost", "ip address that node runs on")
 var nodePortOpt = flag.Int("node_port", 3000, "port that node binds to")
 var webPortOpt = flag.Int("web_port", 8000, "port that node binds to")
+var bootstrapOpt = flag.String("bootstrap", "", "address of bootstrap node that node joins network through on start")
+var clusterOpt = flag.String("cluster", "", "cluster that node joins, every node is head of itself if empty")
+var clusterHeadOpt = flag.String("cluster_head", "", "public key of cluster head, elect head among cluster members if empty")
+var lightOpt = flag.Bool("light", false, "run as light node, which keeps only block headers and asks other nodes for transactions")
+var keyFileOpt = flag.String("key", "", "file that key pair of node is loaded from, it's generated and saved there if missing")
+var keyFormatOpt = flag.String("key_format", core.KeyFormatPEM, "format that new key file is saved in, pem or raw")
+var keyTypeOpt = flag.String("key_type", "ecdsa", "signature scheme of key pair generated for new key file, ecdsa or ed25519")
+var dataDirOpt = flag.String("data", "", "directory that node state is stored in, keep state only in memory if empty")
 
 var l *core.Logger
 
@@ -21,7 +29,7 @@ func init() {
 var initString = "                                 _                   \n          (_)                   | |         (_)      \n _ __ ___  _  ___ _ __ ___   ___| |__   __ _ _ _ __  \n| '_ ` _ \\| |/ __| '__/ _ \\ / __| '_ \\ / _` | | '_ \\ \n| | | | | | | (__| | | (_) | (__| | | | (_| | | | | |\n|_| |_| |_|_|\\___|_|  \\___/ \\___|_| |_|\\__,_|_|_| |_|\n"
 
 func main() {
-	c, err := newClient(*nodeIPOpt, *nodePortOpt, *webPortOpt, l)
+	c, err := newClient(*nodeIPOpt, *nodePortOpt, *webPortOpt, *dataDirOpt, *lightOpt, *keyFileOpt, *keyFormatOpt, *keyTypeOpt, l)
 	if err != nil {
 		l.Error.Println(err)
 		return
@@ -29,5 +37,13 @@ func main() {
 
 	c.terminal <- initString
 
+	if *clusterOpt != "" {
+		c.node.SetCluster(*clusterOpt, core.Base58Decode(*clusterHeadOpt))
+	}
+
+	if *bootstrapOpt != "" {
+		go c.joinNetwork(*bootstrapOpt)
+	}
+
 	c.repl()
 }
@@ -59,7 +59,13 @@ func (c *client) repl() {
 			}()
 		} else if joinNetworkOpt.MatchString(input) {
 			// Join p2p network through node.
-			// TODO: I don't want to implement this block now. Because I have very limited time to submit my final project.
+			b, msg, addr := checkJoinNetworkCommand(input)
+			if !b {
+				c.terminal <- msg
+				continue
+			}
+
+			go c.joinNetwork(addr)
 		} else if queryTransactionsOpt.MatchString(input) {
 			b, msg := checkQueryTransactionsCommand(input)
 			if !b {
@@ -78,6 +84,24 @@ func (c *client) repl() {
 					core.Base58Encode(t.RequesteePK()),
 					string(t.Meta), t.Timestamp())
 			}
+		} else if queryBlocksOpt.MatchString(input) {
+			// Query blocks of chain.
+			b, msg := checkQueryBlocksCommand(input)
+			if !b {
+				c.terminal <- msg
+				continue
+			}
+
+			n, bs := c.node.GetBlocksOfChain()
+
+			c.terminal <- fmt.Sprintf("Currently, there are %d blocks\n", n)
+
+			for _, b := range bs {
+				c.terminal <- "---\n"
+				c.terminal <- fmt.Sprintf("Generator   : %s\nPrevious    : %s\nTimestamp   : %d\nTransactions: %d\n", core.Base58Encode(b.Header.GeneratorID),
+					core.Base58Encode(b.Header.PrevBlockID),
+					b.Header.Timestamp, len(b.Transactions))
+			}
 		} else if sendTransactionOpt.MatchString(input) {
 			// Send transaction to given node.
 			b, msg, id, data := checkSendTransactionCommand(input)
@@ -137,6 +161,28 @@ func (c *client) repl() {
 
 			// TODO:
 			c.confirmPendingTransaction(id)
+		} else if rejectReqOpt.MatchString(input) {
+			b, msg, id := checkRejectCommand(input)
+			if !b {
+				c.terminal <- msg
+				continue
+			}
+
+			c.rejectPendingTransaction(id)
+		} else if rotateKeyOpt.MatchString(input) {
+			// Rotate key pair.
+			if b, msg := checkRotateKeyCommand(input); !b {
+				c.terminal <- msg
+				continue
+			}
+
+			err := c.rotateKeyPair()
+			if err != nil {
+				c.terminal <- err.Error() + "\n"
+				continue
+			}
+
+			c.terminal <- fmt.Sprintf("Key pair is rotated, node id is %s now\n", core.Base58Encode(c.node.PublicKey()))
 		} else if input == "" {
 			// Do nothing, intended leaving blank.
 		} else {